@@ -20,6 +20,10 @@ var (
 	NotEnoughGold               = msError.NewError(11, errors.New("钻石不足"))
 	UserDataLocked              = msError.NewError(12, errors.New("用户数据被锁定"))
 	NotEnoughScore              = msError.NewError(13, errors.New("积分不足"))
+	RequestTooFrequent          = msError.NewError(14, errors.New("请求过于频繁"))
+	IpForbidden                 = msError.NewError(15, errors.New("IP 禁止访问"))
+	PhoneAuthDisabled           = msError.NewError(16, errors.New("未开启手机号验证"))
+	WxAuthFailed                = msError.NewError(17, errors.New("微信授权失败"))
 	AccountOrPasswordError      = msError.NewError(101, errors.New("账号或密码错误"))
 	GetHallServersFail          = msError.NewError(102, errors.New("获取大厅服务器失败"))
 	AccountExist                = msError.NewError(103, errors.New("账号已存在"))
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
+	"log"
+	"sync"
+	"time"
 )
 
 // Config 反引号标记字段的元数据
@@ -21,6 +24,13 @@ type Config struct {
 	Etcd       EtcdConf                `mapstructure:"etcd"`
 	Domain     map[string]Domain       `mapstructure:"domain"`
 	Services   map[string]ServicesConf `mapstructure:"services"`
+	Session    SessionConf             `mapstructure:"session"`
+	Trace      TraceConf               `mapstructure:"trace"`
+	Shutdown   ShutdownConf            `mapstructure:"shutdown"`
+	Sms        SmsConf                 `mapstructure:"sms"`
+	Wx         WxConf                  `mapstructure:"wx"`
+	Admin      AdminConf               `mapstructure:"admin"`
+	Security   SecurityConf            `mapstructure:"security"`
 }
 type ServicesConf struct {
 	Id         string `mapstructure:"id"`
@@ -28,15 +38,92 @@ type ServicesConf struct {
 	ClientPort int    `mapstructure:"clientPort"`
 }
 type Domain struct {
-	Name        string `mapstructure:"name"`
-	LoadBalance bool   `mapstructure:"loadBalance"`
+	Name        string  `mapstructure:"name"`
+	LoadBalance bool    `mapstructure:"loadBalance"`
+	Tls         TlsConf `mapstructure:"tls"` // 调用该服务时使用的 TLS，caFile 为空时不加密
 }
 type JwtConf struct {
-	Secret string `mapstructure:"secret"`
-	Exp    int64  `mapstructure:"exp"`
+	Secret     string   `mapstructure:"secret"`     // kid 为空的 HS256 密钥，兼容没有 kid 的旧 token，不需要时可以删除
+	Exp        int64    `mapstructure:"exp"`        // access token 的有效期（单位：秒）
+	RefreshExp int64    `mapstructure:"refreshExp"` // refresh token 的有效期（单位：秒）
+	ActiveKid  string   `mapstructure:"activeKid"`  // 签发 token 使用的密钥，为空时使用 secret
+	Keys       []JwtKey `mapstructure:"keys"`       // 按 kid 区分的密钥，验证时使用所有未退役的密钥
+}
+type JwtKey struct {
+	Kid        string `mapstructure:"kid"`
+	Alg        string `mapstructure:"alg"`        // HS256(默认)、RS256、EdDSA
+	Secret     string `mapstructure:"secret"`     // HS256 的密钥
+	PublicKey  string `mapstructure:"publicKey"`  // RS256、EdDSA 的公钥 PEM 文件，只验证 token 的服务只需要公钥
+	PrivateKey string `mapstructure:"privateKey"` // RS256、EdDSA 的私钥 PEM 文件，只在签发 token 的服务上配置
+	Retired    bool   `mapstructure:"retired"`    // 已退役的密钥不再用于验证
+}
+
+// GetExp access token 的有效期，未配置时为 2 小时
+func (c JwtConf) GetExp() time.Duration {
+	if c.Exp <= 0 {
+		return 2 * time.Hour
+	}
+	return time.Duration(c.Exp) * time.Second
+}
+
+// GetRefreshExp refresh token 的有效期，未配置时为 7 天
+func (c JwtConf) GetRefreshExp() time.Duration {
+	if c.RefreshExp <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(c.RefreshExp) * time.Second
+}
+
+type TraceConf struct {
+	Exporter    string  `mapstructure:"exporter"`    // span 的输出方式：stdout、file，为空时不输出，只用于日志关联
+	File        string  `mapstructure:"file"`        // exporter 为 file 时的输出文件
+	SampleRatio float64 `mapstructure:"sampleRatio"` // 采样比例，0 到 1，未配置时全部采样
+}
+type ShutdownConf struct {
+	Timeout int64 `mapstructure:"timeout"` // 优雅停止时等待请求和推送完成的最长时间（单位：秒）
+}
+
+// GetTimeout 优雅停止的最长等待时间，未配置时为 10 秒
+func (c ShutdownConf) GetTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+type SmsConf struct {
+	Provider string `mapstructure:"provider"` // 短信服务商，目前只有 log：只把验证码打印到日志中
+	CodeTtl  int64  `mapstructure:"codeTtl"`  // 验证码的有效期（单位：秒）
+	Interval int64  `mapstructure:"interval"` // 同一手机号两次发送的最小间隔（单位：秒）
+}
+type WxConf struct {
+	AppId  string `mapstructure:"appId"`  // 微信小游戏的 appId，和 secret 都配置后微信注册、登录、绑定的 account 改为 wx.login 的 code
+	Secret string `mapstructure:"secret"` // 微信小游戏的 appSecret，用于 code2session 校验 code，未配置时直接信任客户端传来的微信账号
+}
+type AdminConf struct {
+	Token string `mapstructure:"token"` // 调用管理接口时 Admin-Token 头需要携带的令牌，为空时关闭管理接口
+}
+type SecurityConf struct {
+	Allow          []string `mapstructure:"allow"`          // IP 白名单（CIDR 或单个 IP），不为空时只允许名单中的 IP，修改后自动生效
+	Deny           []string `mapstructure:"deny"`           // IP 黑名单（CIDR 或单个 IP），修改后自动生效
+	TrustedProxies []string `mapstructure:"trustedProxies"` // gate、connector 前面的代理地址，只信任这些代理传来的 X-Forwarded-For
+	RegisterLimit  int      `mapstructure:"registerLimit"`  // gate 每个 IP 每分钟最多注册的次数，0 表示不限制
+	LoginLimit     int      `mapstructure:"loginLimit"`     // gate 每个 IP 每分钟最多登录的次数，0 表示不限制
+	SmsLimit       int      `mapstructure:"smsLimit"`       // gate 每个 IP 每小时最多发送短信验证码的次数，0 表示不限制
+	MaxConnPerIp   int      `mapstructure:"maxConnPerIp"`   // connector 每个 IP 最多的连接数，0 表示不限制，修改后自动生效
+}
+type SessionConf struct {
+	Ttl int64 `mapstructure:"ttl"` // 会话数据在 redis 中的保存时长（单位：秒）
 }
 type LogConf struct {
-	Level string `mapstructure:"level"`
+	Level       string `mapstructure:"level"`
+	Format      string `mapstructure:"format"`      // text(默认) 或 json
+	File        string `mapstructure:"file"`        // 日志文件路径，为空时输出到 stderr
+	MaxSize     int    `mapstructure:"maxSize"`     // 单个日志文件的最大大小（单位：MB）
+	MaxAge      int    `mapstructure:"maxAge"`      // 旧日志文件的保留天数
+	MaxBackups  int    `mapstructure:"maxBackups"`  // 旧日志文件的最多保留个数
+	RotateHours int    `mapstructure:"rotateHours"` // 按时间切割的间隔（单位：小时），为 0 时只按大小切割
+	Compress    bool   `mapstructure:"compress"`    // 是否压缩旧日志文件
 }
 
 // Database 数据库配置
@@ -75,12 +162,53 @@ type RegisterServer struct {
 	Ttl     int64  `mapstructure:"ttl"` //租约时长
 }
 type GrpcConf struct {
-	Addr string `mapstructure:"addr"`
+	Addr    string  `mapstructure:"addr"`
+	Timeout int64   `mapstructure:"timeout"` // 处理一个请求的最长时间（单位：秒），客户端的 deadline 更短时以客户端为准
+	Tls     TlsConf `mapstructure:"tls"`     // 服务端的 TLS，certFile 为空时不加密
+}
+
+// GetTimeout 处理一个请求的最长时间，未配置时为 5 秒
+func (c GrpcConf) GetTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+type TlsConf struct {
+	CertFile   string `mapstructure:"certFile"`   // 证书 PEM 文件，客户端配置后使用双向认证
+	KeyFile    string `mapstructure:"keyFile"`    // 私钥 PEM 文件
+	CaFile     string `mapstructure:"caFile"`     // 服务端：配置后要求并校验客户端证书；客户端：校验服务端证书的 CA
+	ServerName string `mapstructure:"serverName"` // 客户端校验服务端证书时使用的名称，etcd 中注册的是 IP 时需要配置
 }
 
 // Conf 声明一个指向Config结构体的指针
 var Conf *Config
 
+var (
+	changeLock     sync.RWMutex
+	changeHandlers []func(conf *Config)
+	// reloadError 配置文件修改后重新解析失败时回调，默认输出到 stderr，日志初始化后改为写入日志
+	reloadError = func(err error) {
+		log.Printf("config file changed, unmarshal err:%v", err)
+	}
+)
+
+// OnChange 订阅 application.yml 的修改，重新解析成功后以新的配置回调。
+// Conf 在启动后不会被替换，需要热更新的配置由订阅方在回调中自行保存。
+func OnChange(fn func(conf *Config)) {
+	changeLock.Lock()
+	defer changeLock.Unlock()
+	changeHandlers = append(changeHandlers, fn)
+}
+
+// OnReloadError 设置配置文件修改后重新解析失败时的处理，common/logs 依赖本包，由日志初始化时注入
+func OnReloadError(fn func(err error)) {
+	changeLock.Lock()
+	defer changeLock.Unlock()
+	reloadError = fn
+}
+
 // InitConfig 加载配置
 // 这个函数用于初始化配置文件，并将其内容解析到全局变量 Conf 中。
 func InitConfig(confFile string) {
@@ -92,6 +220,17 @@ func InitConfig(confFile string) {
 	v.WatchConfig()
 	v.OnConfigChange(func(e fsnotify.Event) {
 		fmt.Println("config file changed:", e.Name)
+		conf := new(Config)
+		changeLock.RLock()
+		handlers, onError := changeHandlers, reloadError
+		changeLock.RUnlock()
+		if err := v.Unmarshal(conf); err != nil {
+			onError(err)
+			return
+		}
+		for _, fn := range handlers {
+			fn(conf)
+		}
 	})
 
 	// 尝试读取配置文件。
@@ -50,3 +50,8 @@ func (m *MongoManger) Close() {
 		logs.Error("mongo disconnect err:", err)
 	}
 }
+
+// Ping 检查 mongo 的连接
+func (m *MongoManger) Ping(ctx context.Context) error {
+	return m.Client.Ping(ctx, readpref.Primary())
+}
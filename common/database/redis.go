@@ -89,3 +89,11 @@ func (r *RedisManger) Set(ctx context.Context, key string, value string, expire
 	}
 	return nil
 }
+
+// Ping 检查 redis 的连接
+func (r *RedisManger) Ping(ctx context.Context) error {
+	if r.Client != nil {
+		return r.Client.Ping(ctx).Err()
+	}
+	return r.ClusterClient.Ping(ctx).Err()
+}
@@ -0,0 +1,183 @@
+package discovery
+
+// 加权且感知健康状态的负载均衡：按 Server.Weight 分配流量，连续调用失败的实例暂时摘除，
+// 冷却后以及新上线的实例都会在预热时间内逐步恢复到完整权重
+
+import (
+	"common/logs"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Weighted 负载均衡策略名称，在 grpc 的 LoadBalancingPolicy 中使用
+const Weighted = "weighted"
+
+const (
+	maxFailures = 3                // 连续失败多少次后摘除实例
+	coolDown    = 10 * time.Second // 摘除后多久重新接收流量
+	warmUp      = 30 * time.Second // 预热时长，期间权重从 1 逐步增加到完整权重
+)
+
+func init() {
+	balancer.Register(weightedBuilder{})
+}
+
+// weightedBuilder 为每个 ClientConn 创建独立的负载均衡器，
+// 健康状态按 ClientConn 分开记录，不同服务的实例互不影响
+type weightedBuilder struct{}
+
+func (weightedBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	return base.NewBalancerBuilder(Weighted, &weightedPickerBuilder{
+		health: newHealthTracker(),
+	}, base.Config{HealthCheck: true}).Build(cc, opts)
+}
+
+func (weightedBuilder) Name() string {
+	return Weighted
+}
+
+// instanceState 单个实例的健康状态
+type instanceState struct {
+	failures  int       // 连续失败次数
+	downUntil time.Time // 摘除截止时间
+	since     time.Time // 开始预热的时间
+}
+
+// healthTracker 按地址记录一个 ClientConn 中实例的健康状态，在 picker 重建之间共享
+type healthTracker struct {
+	sync.Mutex
+	states map[string]*instanceState
+}
+
+func newHealthTracker() *healthTracker {
+	return &healthTracker{
+		states: make(map[string]*instanceState),
+	}
+}
+
+// weight 返回实例当前的有效权重，摘除中的实例为 0
+func (h *healthTracker) weight(addr string, weight int, now time.Time) int {
+	h.Lock()
+	defer h.Unlock()
+	state, ok := h.states[addr]
+	if !ok {
+		// 新发现的实例从现在开始预热
+		state = &instanceState{since: now}
+		h.states[addr] = state
+	}
+	if now.Before(state.downUntil) {
+		return 0
+	}
+	if elapsed := now.Sub(state.since); elapsed < warmUp {
+		return max(1, int(int64(weight)*int64(elapsed)/int64(warmUp)))
+	}
+	return weight
+}
+
+// report 记录一次调用结果，没有收到服务端任何数据的失败才算作实例故障，业务错误不计入
+func (h *healthTracker) report(addr string, info balancer.DoneInfo) {
+	h.Lock()
+	defer h.Unlock()
+	state, ok := h.states[addr]
+	if !ok {
+		return
+	}
+	if info.Err == nil || info.BytesReceived {
+		state.failures = 0
+		return
+	}
+	state.failures++
+	if state.failures >= maxFailures {
+		now := time.Now()
+		state.failures = 0
+		state.downUntil = now.Add(coolDown)
+		state.since = state.downUntil
+		logs.Warn("balancer mark %s unhealthy, err:%v", addr, info.Err)
+	}
+}
+
+// forget 清理已经不存在的实例，重新上线时重新预热
+func (h *healthTracker) forget(addrs map[string]bool) {
+	h.Lock()
+	defer h.Unlock()
+	for addr := range h.states {
+		if !addrs[addr] {
+			delete(h.states, addr)
+		}
+	}
+}
+
+type weightedPickerBuilder struct {
+	health *healthTracker
+}
+
+func (b *weightedPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	instances := make([]weightedInstance, 0, len(info.ReadySCs))
+	addrs := make(map[string]bool, len(info.ReadySCs))
+	for sc, scInfo := range info.ReadySCs {
+		weight := 1
+		if scInfo.Address.Attributes != nil {
+			if w, ok := scInfo.Address.Attributes.Value("weight").(int); ok && w > 0 {
+				weight = w
+			}
+		}
+		instances = append(instances, weightedInstance{
+			subConn: sc,
+			addr:    scInfo.Address.Addr,
+			weight:  weight,
+		})
+		addrs[scInfo.Address.Addr] = true
+	}
+	b.health.forget(addrs)
+	return &weightedPicker{
+		instances: instances,
+		health:    b.health,
+	}
+}
+
+type weightedInstance struct {
+	subConn balancer.SubConn
+	addr    string
+	weight  int
+}
+
+type weightedPicker struct {
+	instances []weightedInstance
+	health    *healthTracker
+}
+
+// Pick 按有效权重随机选择实例，所有实例都被摘除时退化为在全部实例中随机选择
+func (p *weightedPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	now := time.Now()
+	weights := make([]int, len(p.instances))
+	total := 0
+	for i, v := range p.instances {
+		weights[i] = p.health.weight(v.addr, v.weight, now)
+		total += weights[i]
+	}
+	var picked weightedInstance
+	if total == 0 {
+		picked = p.instances[rand.Intn(len(p.instances))]
+	} else {
+		n := rand.Intn(total)
+		for i, w := range weights {
+			if n < w {
+				picked = p.instances[i]
+				break
+			}
+			n -= w
+		}
+	}
+	return balancer.PickResult{
+		SubConn: picked.subConn,
+		Done: func(info balancer.DoneInfo) {
+			p.health.report(picked.addr, info)
+		},
+	}, nil
+}
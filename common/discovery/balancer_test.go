@@ -0,0 +1,166 @@
+package discovery
+
+import (
+	"common/config"
+	"common/logs"
+	"errors"
+	"google.golang.org/grpc/balancer"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	config.Conf = &config.Config{}
+	logs.InitLog("test")
+	os.Exit(m.Run())
+}
+
+// fakeSubConn 只用于区分 picker 选中的实例
+type fakeSubConn struct {
+	balancer.SubConn
+	addr string
+}
+
+var (
+	callFailed   = balancer.DoneInfo{Err: errors.New("unavailable")}
+	businessErr  = balancer.DoneInfo{Err: errors.New("not found"), BytesReceived: true}
+	callSucceed  = balancer.DoneInfo{}
+	instanceAddr = "10.0.0.1:8000"
+)
+
+func TestHealthTrackerWarmUp(t *testing.T) {
+	h := newHealthTracker()
+	start := time.Now()
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    int
+	}{
+		{"just discovered", 0, 1},
+		{"half way", warmUp / 2, 5},
+		{"warmed up", warmUp, 10},
+		{"long after", 10 * warmUp, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.weight(instanceAddr, 10, start.Add(tt.elapsed)); got != tt.want {
+				t.Errorf("weight() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthTrackerReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		reports []balancer.DoneInfo
+		down    bool
+	}{
+		{"success", []balancer.DoneInfo{callSucceed}, false},
+		{"failures below limit", repeat(callFailed, maxFailures-1), false},
+		{"consecutive failures", repeat(callFailed, maxFailures), true},
+		{"success resets failures", append(repeat(callFailed, maxFailures-1), callSucceed, callFailed), false},
+		{"business errors are not failures", repeat(businessErr, maxFailures), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHealthTracker()
+			// 先预热完成，之后的权重只受摘除影响
+			h.weight(instanceAddr, 10, time.Now().Add(-warmUp))
+			for _, info := range tt.reports {
+				h.report(instanceAddr, info)
+			}
+			now := time.Now()
+			got := h.weight(instanceAddr, 10, now)
+			if tt.down && got != 0 {
+				t.Fatalf("weight() = %d, want 0 while cooling down", got)
+			}
+			if !tt.down && got != 10 {
+				t.Fatalf("weight() = %d, want 10", got)
+			}
+			if tt.down {
+				// 冷却结束后从 1 开始重新预热
+				if got := h.weight(instanceAddr, 10, now.Add(coolDown+time.Second)); got < 1 || got >= 10 {
+					t.Errorf("weight() after cool down = %d, want warming up", got)
+				}
+				if got := h.weight(instanceAddr, 10, now.Add(coolDown+warmUp+time.Second)); got != 10 {
+					t.Errorf("weight() after warm up = %d, want 10", got)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthTrackerForget(t *testing.T) {
+	h := newHealthTracker()
+	now := time.Now()
+	h.weight("a", 1, now.Add(-warmUp))
+	h.weight("b", 1, now.Add(-warmUp))
+	h.forget(map[string]bool{"a": true})
+	if _, ok := h.states["b"]; ok {
+		t.Errorf("state of removed instance is kept")
+	}
+	if got := h.weight("a", 10, now); got != 10 {
+		t.Errorf("weight() of kept instance = %d, want 10", got)
+	}
+	// 重新上线的实例重新预热
+	if got := h.weight("b", 10, now); got != 1 {
+		t.Errorf("weight() of rediscovered instance = %d, want 1", got)
+	}
+}
+
+func TestWeightedPicker(t *testing.T) {
+	addrs := []string{"a", "b", "c"}
+	tests := []struct {
+		name string
+		down []string
+		want map[string]bool // 允许被选中的实例
+	}{
+		{"all healthy", nil, map[string]bool{"a": true, "b": true, "c": true}},
+		{"skip cooling down", []string{"a", "b"}, map[string]bool{"c": true}},
+		{"all cooling down", addrs, map[string]bool{"a": true, "b": true, "c": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHealthTracker()
+			p := &weightedPicker{health: h}
+			for _, addr := range addrs {
+				p.instances = append(p.instances, weightedInstance{
+					subConn: &fakeSubConn{addr: addr},
+					addr:    addr,
+					weight:  1,
+				})
+				h.weight(addr, 1, time.Now().Add(-warmUp))
+			}
+			for _, addr := range tt.down {
+				for i := 0; i < maxFailures; i++ {
+					h.report(addr, callFailed)
+				}
+			}
+			picked := make(map[string]bool)
+			for i := 0; i < 300; i++ {
+				res, err := p.Pick(balancer.PickInfo{})
+				if err != nil {
+					t.Fatalf("Pick() err = %v", err)
+				}
+				addr := res.SubConn.(*fakeSubConn).addr
+				if !tt.want[addr] {
+					t.Fatalf("Pick() = %s, want one of %v", addr, tt.want)
+				}
+				picked[addr] = true
+			}
+			if len(picked) != len(tt.want) {
+				t.Errorf("picked %v, want all of %v", picked, tt.want)
+			}
+		})
+	}
+}
+
+func repeat(info balancer.DoneInfo, n int) []balancer.DoneInfo {
+	result := make([]balancer.DoneInfo, n)
+	for i := range result {
+		result[i] = info
+	}
+	return result
+}
@@ -0,0 +1,40 @@
+package discovery
+
+// 节点（game、hall、connector）在 etcd 中的注册信息，connector 通过监听节点前缀发现后端节点
+
+import (
+	"common/config"
+	"strings"
+)
+
+// NodeName 节点注册名称的前缀，节点注册的 key 为 "/node/{serverType}/{serverId}"
+const NodeName = "node"
+
+// NodePrefix 监听所有节点时使用的 key 前缀
+const NodePrefix = "/" + NodeName + "/"
+
+// defaultNodeTtl 未配置租约时长时节点使用的租约时间（秒）
+const defaultNodeTtl = 10
+
+// NodeConf 根据 etcd 配置生成节点的注册配置，节点通过 nats 通信，注册地址就是 serverId
+func NodeConf(conf config.EtcdConf, serverType string, serverId string) config.EtcdConf {
+	ttl := conf.Register.Ttl
+	if ttl <= 0 {
+		ttl = defaultNodeTtl
+	}
+	conf.Register = config.RegisterServer{
+		Name:   NodeName + "/" + serverType,
+		Addr:   serverId,
+		Weight: conf.Register.Weight,
+		Ttl:    ttl,
+	}
+	return conf
+}
+
+// NodeType 从节点的注册名称中解析节点类型，不是节点时返回空
+func (s Server) NodeType() string {
+	if !strings.HasPrefix(s.Name, NodeName+"/") {
+		return ""
+	}
+	return strings.TrimPrefix(s.Name, NodeName+"/")
+}
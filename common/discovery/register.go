@@ -7,7 +7,9 @@ import (
 	"common/logs"
 	"context"
 	"encoding/json"
+	"errors"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"sync"
 	"time"
 )
 
@@ -23,7 +25,9 @@ type Register struct {
 	Dialtimeout int                                     // 连接超时时间
 	ttl         int                                     // 租约时间（秒）
 	keepAliveCh <-chan *clientv3.LeaseKeepAliveResponse // 心跳响应通道
+	infoLock    sync.Mutex                              // 保护 info 和租约，Publish 和重新注册可能同时发生
 	info        Server                                  // 注册的 Server 信息
+	notServing  bool                                    // 健康检查失败，暂时从 etcd 中移除，租约继续保持
 	closeCh     chan struct{}                           // 用于关闭注册器的通道
 }
 
@@ -77,6 +81,8 @@ func (r *Register) Close() {
 
 // register 注册服务到 etcd，并绑定租约
 func (r *Register) register() error {
+	r.infoLock.Lock()
+	defer r.infoLock.Unlock()
 	// 创建租约
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.Dialtimeout)*time.Second)
 	defer cancel()
@@ -92,16 +98,64 @@ func (r *Register) register() error {
 		return err
 	}
 
+	if r.notServing {
+		return nil
+	}
 	// 绑定租约，将服务信息注册到 etcd
 	data, _ := json.Marshal(r.info)
 	return r.bindLease(ctx, r.info.BuildRegisterKey(), string(data))
 }
 
+// Publish 修改注册信息（例如 connector 的连接数）并重新写入 etcd，继续使用原来的租约
+func (r *Register) Publish(update func(info *Server)) error {
+	r.infoLock.Lock()
+	defer r.infoLock.Unlock()
+	update(&r.info)
+	if r.etcdCli == nil {
+		return errors.New("etcd not connected")
+	}
+	if r.notServing {
+		// 恢复服务时会写入最新的信息
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.Dialtimeout)*time.Second)
+	defer cancel()
+	data, _ := json.Marshal(r.info)
+	return r.bindLease(ctx, r.info.BuildRegisterKey(), string(data))
+}
+
+// SetServing 根据服务的健康状态注册或移除服务，移除后客户端不再把请求发过来，恢复后重新注册
+func (r *Register) SetServing(serving bool) error {
+	r.infoLock.Lock()
+	defer r.infoLock.Unlock()
+	if r.notServing == !serving {
+		return nil
+	}
+	if r.etcdCli == nil {
+		return errors.New("etcd not connected")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.Dialtimeout)*time.Second)
+	defer cancel()
+	var err error
+	if serving {
+		data, _ := json.Marshal(r.info)
+		err = r.bindLease(ctx, r.info.BuildRegisterKey(), string(data))
+	} else {
+		_, err = r.etcdCli.Delete(ctx, r.info.BuildRegisterKey())
+	}
+	if err != nil {
+		return err
+	}
+	r.notServing = !serving
+	logs.Info("set service serving=%v, key=%s", serving, r.info.BuildRegisterKey())
+	return nil
+}
+
 // bindLease 将服务信息绑定到租约
 func (r *Register) bindLease(ctx context.Context, key string, value string) error {
 	_, err := r.etcdCli.Put(ctx, key, value, clientv3.WithLease(r.leaseId))
 	if err != nil {
-		logs.Error("bind register err:", err)
+		logs.Error("bind register err:%v", err)
 		return err
 	}
 	return nil
@@ -111,7 +165,7 @@ func (r *Register) bindLease(ctx context.Context, key string, value string) erro
 func (r *Register) createLease(ctx context.Context, ttl int64) error {
 	grant, err := r.etcdCli.Grant(ctx, ttl)
 	if err != nil {
-		logs.Error("create grant err:", err)
+		logs.Error("create grant err:%v", err)
 		return err
 	}
 	r.leaseId = grant.ID
@@ -124,7 +178,7 @@ func (r *Register) keepAlive() (<-chan *clientv3.LeaseKeepAliveResponse, error)
 	// 不停地发消息，保持租约（续租）
 	keepAliveResponses, err := r.etcdCli.KeepAlive(context.Background(), r.leaseId)
 	if err != nil {
-		logs.Error("keep alive err:", err)
+		logs.Error("keep alive err:%v", err)
 		return keepAliveResponses, err
 	}
 	return keepAliveResponses, nil
@@ -140,17 +194,17 @@ func (r *Register) watcher() {
 			// 收到关闭信号，注销服务并撤销租约
 			err := r.unregister()
 			if err != nil {
-				logs.Error("unregister err:", err)
+				logs.Error("unregister err:%v", err)
 			}
 			// 撤销租约
 			_, err = r.etcdCli.Revoke(context.Background(), r.leaseId)
 			if err != nil {
-				logs.Error("revoke err:", err)
+				logs.Error("revoke err:%v", err)
 			}
 			if r.etcdCli != nil {
 				err := r.etcdCli.Close()
 				if err != nil {
-					logs.Error("close etcd err:", err)
+					logs.Error("close etcd err:%v", err)
 					return
 				}
 			}
@@ -160,7 +214,7 @@ func (r *Register) watcher() {
 			// 收到心跳响应，如果响应为空(etcd断开重连)，重新注册服务
 			if res == nil {
 				if err := r.register(); err != nil {
-					logs.Error("keepAliveCh register err:", err)
+					logs.Error("keepAliveCh register err:%v", err)
 				}
 				logs.Info("重新续约成功")
 			}
@@ -168,7 +222,7 @@ func (r *Register) watcher() {
 			// 定时检查心跳通道是否为空，如果为空，重新注册服务
 			if r.keepAliveCh == nil {
 				if err := r.register(); err != nil {
-					logs.Error("ticker register err:", err)
+					logs.Error("ticker register err:%v", err)
 				}
 			}
 		}
@@ -179,7 +233,28 @@ func (r *Register) watcher() {
 func (r *Register) unregister() error {
 	_, err := r.etcdCli.Delete(context.Background(), r.info.BuildRegisterKey())
 	if err != nil {
-		logs.Error("unregister err:", err)
+		logs.Error("unregister err:%v", err)
 	}
 	return err
 }
+
+// Check 检查 etcd 的连接，以及服务是否仍然注册在 etcd 中
+func (r *Register) Check(ctx context.Context) error {
+	if r.etcdCli == nil {
+		return errors.New("etcd not connected")
+	}
+	r.infoLock.Lock()
+	notServing := r.notServing
+	r.infoLock.Unlock()
+	if notServing {
+		return errors.New("service not serving")
+	}
+	res, err := r.etcdCli.Get(ctx, r.info.BuildRegisterKey(), clientv3.WithCountOnly())
+	if err != nil {
+		return err
+	}
+	if res.Count == 0 {
+		return errors.New("service not registered")
+	}
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"common/config"
 	"common/logs"
 	"context"
+	"errors"
+	"fmt"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc/attributes"
 	"google.golang.org/grpc/resolver"
@@ -235,3 +237,18 @@ func NewResolver(conf config.EtcdConf) *Resolver {
 		conf:        conf,
 	}
 }
+
+// Check 检查 etcd 的连接，以及是否有可用的服务实例
+func (r *Resolver) Check(ctx context.Context) error {
+	if r.etcdCli == nil {
+		return errors.New("etcd not connected")
+	}
+	res, err := r.etcdCli.Get(ctx, r.key, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return err
+	}
+	if res.Count == 0 {
+		return fmt.Errorf("no available service, name=%s", r.key)
+	}
+	return nil
+}
@@ -17,6 +17,10 @@ type Server struct {
 	Version string `json:"version"` // 服务版本
 	Weight  int    `json:"weight"`  // 服务权重
 	Ttl     int64  `json:"ttl"`     // 服务的生存时间（TTL）
+
+	ClientHost string `json:"clientHost,omitempty"` // connector 给客户端连接的地址
+	ClientPort int    `json:"clientPort,omitempty"` // connector 给客户端连接的端口
+	Load       int    `json:"load,omitempty"`       // connector 当前的连接数
 }
 
 // BuildRegisterKey 构建服务注册的键
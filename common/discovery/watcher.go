@@ -0,0 +1,173 @@
+package discovery
+
+// 监听 etcd 中指定前缀下注册的服务，维护实时的服务列表，列表变化时回调通知
+
+import (
+	"common/config"
+	"common/logs"
+	"context"
+	"errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"sort"
+	"time"
+)
+
+// Watcher 监听指定前缀下的服务注册信息
+type Watcher struct {
+	etcdCli  *clientv3.Client
+	conf     config.EtcdConf
+	prefix   string
+	servers  map[string]Server // key 为注册的 key
+	onChange func(servers []Server)
+	closeCh  chan struct{}
+}
+
+// NewWatcher 创建一个监听 prefix 前缀的 Watcher
+func NewWatcher(conf config.EtcdConf, prefix string) *Watcher {
+	return &Watcher{
+		conf:    conf,
+		prefix:  prefix,
+		servers: make(map[string]Server),
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Watch 连接 etcd 并同步一次服务列表，之后在协程中从同步时的版本开始监听变化，每次变化都会回调 onChange
+func (w *Watcher) Watch(onChange func(servers []Server)) error {
+	var err error
+	w.etcdCli, err = clientv3.New(clientv3.Config{
+		Endpoints:   w.conf.Addrs,
+		DialTimeout: time.Duration(w.conf.DialTimeout) * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+	w.onChange = onChange
+	rev, err := w.sync()
+	if err != nil {
+		_ = w.etcdCli.Close()
+		return err
+	}
+	go w.watch(rev)
+	return nil
+}
+
+// Close 停止监听并关闭 etcd 客户端
+func (w *Watcher) Close() {
+	close(w.closeCh)
+}
+
+// watch 监听 rev 之后的变化，同步和监听之间注册、注销的服务不会漏掉
+func (w *Watcher) watch(rev int64) {
+	// 定时全量同步一次，防止漏掉事件
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	watchCh := w.watchFrom(ctx, rev)
+	// resync 全量同步后从同步时的版本重新监听，同步失败时从最后收到的版本继续监听
+	resync := func() {
+		cancel()
+		if r, err := w.sync(); err != nil {
+			logs.Error("watcher sync failed, prefix=%s, err:%v", w.prefix, err)
+			// etcd 不可用时避免不停地重试
+			time.Sleep(time.Second)
+		} else {
+			rev = r
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+		watchCh = w.watchFrom(ctx, rev)
+	}
+	for {
+		select {
+		case <-w.closeCh:
+			cancel()
+			logs.Info("close watcher, prefix=%s", w.prefix)
+			if err := w.etcdCli.Close(); err != nil {
+				logs.Error("close etcd err:%v", err)
+			}
+			return
+		case res, ok := <-watchCh:
+			if !ok || res.Err() != nil {
+				// 监听通道被关闭（etcd 断开），或者监听的版本已经被压缩（ErrCompacted），重新同步并监听
+				if ok {
+					logs.Warn("watcher err, prefix=%s, err:%v", w.prefix, res.Err())
+				}
+				resync()
+				continue
+			}
+			w.update(res.Events)
+			rev = res.Header.Revision
+		case <-ticker.C:
+			resync()
+		}
+	}
+}
+
+// watchFrom 监听 rev 之后的变化
+func (w *Watcher) watchFrom(ctx context.Context, rev int64) clientv3.WatchChan {
+	return w.etcdCli.Watch(clientv3.WithRequireLeader(ctx), w.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+}
+
+// sync 从 etcd 中全量读取服务列表，返回读取时的版本
+func (w *Watcher) sync() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(w.conf.RWTimeout)*time.Second)
+	defer cancel()
+	res, err := w.etcdCli.Get(ctx, w.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, err
+	}
+	servers := make(map[string]Server, len(res.Kvs))
+	for _, v := range res.Kvs {
+		server, err := ParseValue(v.Value)
+		if err != nil {
+			logs.Error("parse etcd register service failed, key=%s, err:%v", v.Key, err)
+			continue
+		}
+		servers[string(v.Key)] = server
+	}
+	w.servers = servers
+	w.notify()
+	return res.Header.Revision, nil
+}
+
+// update 根据 etcd 事件更新服务列表
+func (w *Watcher) update(events []*clientv3.Event) {
+	for _, ev := range events {
+		key := string(ev.Kv.Key)
+		switch ev.Type {
+		case clientv3.EventTypePut:
+			server, err := ParseValue(ev.Kv.Value)
+			if err != nil {
+				logs.Error("parse etcd register service failed, key=%s, err:%v", key, err)
+				continue
+			}
+			w.servers[key] = server
+		case clientv3.EventTypeDelete:
+			delete(w.servers, key)
+		}
+	}
+	w.notify()
+}
+
+// notify 按注册的 key 排序后回调
+func (w *Watcher) notify() {
+	keys := make([]string, 0, len(w.servers))
+	for k := range w.servers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	servers := make([]Server, 0, len(keys))
+	for _, k := range keys {
+		servers = append(servers, w.servers[k])
+	}
+	w.onChange(servers)
+}
+
+// Check 检查 etcd 的连接
+func (w *Watcher) Check(ctx context.Context) error {
+	if w.etcdCli == nil {
+		return errors.New("etcd not connected")
+	}
+	_, err := w.etcdCli.Get(ctx, w.prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	return err
+}
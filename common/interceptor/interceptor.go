@@ -0,0 +1,61 @@
+package interceptor
+
+import (
+	"common/logs"
+	"common/tracing"
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"runtime/debug"
+	"time"
+)
+
+// grpc 服务端的通用拦截器：请求日志、panic 恢复、请求超时，链路追踪和调用统计分别在 tracing 和 metrics 中
+
+// Logging 记录每次调用的方法、耗时和返回的状态码，业务错误码记为 info，服务端错误记为 error
+func Logging() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		code := status.Code(err)
+		entry := logs.With(logs.Route, info.FullMethod, logs.TraceId, tracing.TraceId(ctx))
+		switch code {
+		case codes.Unknown, codes.Internal, codes.DeadlineExceeded, codes.Unavailable, codes.DataLoss:
+			entry.Error("grpc cost=%v, code=%s, err=%v", time.Since(start), code, err)
+		default:
+			entry.Info("grpc cost=%v, code=%s", time.Since(start), code)
+		}
+		return resp, err
+	}
+}
+
+// Recovery 捕获处理函数中的 panic，记录堆栈并返回 Internal，避免整个服务退出
+func Recovery() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				logs.With(logs.Route, info.FullMethod, logs.TraceId, tracing.TraceId(ctx)).
+					Error("grpc handler panic, err=%v\n%s", e, debug.Stack())
+				resp, err = nil, status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// Deadline 限制每次调用的最长处理时间，客户端没有设置 deadline 或者 deadline 更长时使用 timeout，
+// 到达服务端时已经超时的请求直接返回，不再处理
+func Deadline(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > timeout {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
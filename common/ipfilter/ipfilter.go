@@ -0,0 +1,201 @@
+package ipfilter
+
+import (
+	"common/config"
+	"common/logs"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// 按 IP 过滤请求和连接：黑名单中的 IP 一律拒绝，白名单不为空时只允许白名单中的 IP，
+// 名单从 security 配置中加载，配置文件修改后自动重新加载
+
+// Filter CIDR 黑白名单
+type Filter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// New 解析黑白名单，名单中可以是 CIDR（10.0.0.0/8）或者单个 IP
+func New(allow, deny []string) (*Filter, error) {
+	f := &Filter{}
+	var err error
+	if f.allow, err = parsePrefixes(allow); err != nil {
+		return nil, err
+	}
+	if f.deny, err = parsePrefixes(deny); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Allow 判断 ip 是否允许访问，无法解析的 ip 在配置了白名单时拒绝
+func (f *Filter) Allow(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return len(f.allow) == 0
+	}
+	addr = addr.Unmap()
+	for _, p := range f.deny {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, p := range f.allow {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePrefixes(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ip %q: %v", s, err)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q: %v", s, err)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
+
+// Proxies 受信任的代理地址，只有这些地址转发的请求才读取 X-Forwarded-For、X-Real-IP
+type Proxies []netip.Prefix
+
+// NewProxies 解析受信任的代理，可以是 CIDR 或者单个 IP
+func NewProxies(list []string) (Proxies, error) {
+	return parsePrefixes(list)
+}
+
+func (p Proxies) contains(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIp 取出请求的客户端 IP，和 gin 的 ClientIP 规则一致：直接连接的地址是受信任的代理时，
+// 从右往左取 X-Forwarded-For 中第一个不受信任的地址，没有时使用 X-Real-IP；
+// 否则忽略这些头，直接使用连接的地址，避免客户端伪造 IP
+func (p Proxies) ClientIp(r *http.Request) string {
+	ip := RemoteIp(r.RemoteAddr)
+	if !p.contains(ip) {
+		return ip
+	}
+	if header := r.Header.Get("X-Forwarded-For"); header != "" {
+		items := strings.Split(header, ",")
+		for i := len(items) - 1; i >= 0; i-- {
+			item := strings.TrimSpace(items[i])
+			if _, err := netip.ParseAddr(item); err != nil {
+				break
+			}
+			if i == 0 || !p.contains(item) {
+				return item
+			}
+		}
+	}
+	if header := strings.TrimSpace(r.Header.Get("X-Real-IP")); header != "" {
+		if _, err := netip.ParseAddr(header); err == nil {
+			return header
+		}
+	}
+	return ip
+}
+
+// RemoteIp 取出 host:port 中的 host，没有端口时原样返回
+func RemoteIp(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+var (
+	lock    sync.RWMutex
+	filter  *Filter
+	proxies Proxies
+)
+
+// Init 根据 security 配置加载黑白名单和受信任的代理，配置文件修改后重新加载，新的配置有误时继续使用旧的名单
+func Init() error {
+	f, p, err := load(config.Conf.Security)
+	if err != nil {
+		return err
+	}
+	lock.Lock()
+	filter, proxies = f, p
+	lock.Unlock()
+	config.OnChange(func(conf *config.Config) {
+		f, p, err := load(conf.Security)
+		if err != nil {
+			logs.Error("reload ip filter err:%v, keep old list", err)
+			return
+		}
+		lock.Lock()
+		filter, proxies = f, p
+		lock.Unlock()
+		logs.Info("ip filter reloaded, allow=%d, deny=%d, trustedProxies=%d", len(f.allow), len(f.deny), len(p))
+	})
+	return nil
+}
+
+func load(conf config.SecurityConf) (*Filter, Proxies, error) {
+	f, err := New(conf.Allow, conf.Deny)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := NewProxies(conf.TrustedProxies)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, p, nil
+}
+
+// ClientIp 使用当前受信任的代理取出请求的客户端 IP，没有初始化时直接使用连接的地址
+func ClientIp(r *http.Request) string {
+	lock.RLock()
+	p := proxies
+	lock.RUnlock()
+	return p.ClientIp(r)
+}
+
+// Allow 使用当前的黑白名单判断 ip 是否允许访问，没有初始化时全部允许
+func Allow(ip string) bool {
+	lock.RLock()
+	f := filter
+	lock.RUnlock()
+	if f == nil {
+		return true
+	}
+	return f.Allow(ip)
+}
@@ -0,0 +1,101 @@
+package ipfilter
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFilterAllow(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow []string
+		deny  []string
+		ip    string
+		want  bool
+	}{
+		{"no list", nil, nil, "1.2.3.4", true},
+		{"denied cidr", nil, []string{"10.0.0.0/8"}, "10.1.2.3", false},
+		{"outside denied cidr", nil, []string{"10.0.0.0/8"}, "11.0.0.1", true},
+		{"denied single ip", nil, []string{"1.2.3.4"}, "1.2.3.4", false},
+		{"allowed cidr", []string{"192.168.0.0/16"}, nil, "192.168.1.1", true},
+		{"outside allowed cidr", []string{"192.168.0.0/16"}, nil, "192.169.0.1", false},
+		{"deny wins over allow", []string{"10.0.0.0/8"}, []string{"10.0.0.1"}, "10.0.0.1", false},
+		{"ipv4 mapped ipv6", nil, []string{"10.0.0.0/8"}, "::ffff:10.0.0.1", false},
+		{"ipv6 cidr", []string{"2001:db8::/32"}, nil, "2001:db8::1", true},
+		{"invalid ip without allow list", nil, []string{"10.0.0.0/8"}, "bad", true},
+		{"invalid ip with allow list", []string{"10.0.0.0/8"}, nil, "bad", false},
+		{"non canonical cidr", nil, []string{"10.1.2.3/8"}, "10.200.0.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.allow, tt.deny)
+			if err != nil {
+				t.Fatalf("New() err = %v", err)
+			}
+			if got := f.Allow(tt.ip); got != tt.want {
+				t.Errorf("Allow(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, list := range [][]string{{"10.0.0.0/33"}, {"not-an-ip"}, {"1.2.3"}} {
+		if _, err := New(list, nil); err == nil {
+			t.Errorf("New(%v) err = nil, want error", list)
+		}
+	}
+}
+
+func TestProxiesClientIp(t *testing.T) {
+	proxies, err := NewProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("NewProxies() err = %v", err)
+	}
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		realIp string
+		want   string
+	}{
+		{"direct client", "1.2.3.4:5000", "", "", "1.2.3.4"},
+		{"spoofed xff from untrusted peer", "1.2.3.4:5000", "9.9.9.9", "", "1.2.3.4"},
+		{"spoofed x-real-ip from untrusted peer", "1.2.3.4:5000", "", "9.9.9.9", "1.2.3.4"},
+		{"client behind trusted proxy", "10.0.0.1:5000", "8.8.8.8", "", "8.8.8.8"},
+		{"skip trusted hops from the right", "10.0.0.1:5000", "8.8.8.8, 192.168.1.1, 10.0.0.2", "", "8.8.8.8"},
+		{"spoofed prefix is ignored", "10.0.0.1:5000", "9.9.9.9, 8.8.8.8", "", "8.8.8.8"},
+		{"all hops trusted", "10.0.0.1:5000", "10.1.1.1, 10.2.2.2", "", "10.1.1.1"},
+		{"invalid xff falls back to x-real-ip", "10.0.0.1:5000", "bad", "7.7.7.7", "7.7.7.7"},
+		{"invalid headers fall back to peer", "10.0.0.1:5000", "bad", "bad", "10.0.0.1"},
+		{"no headers from trusted proxy", "10.0.0.1:5000", "", "", "10.0.0.1"},
+		{"ipv6 peer", "[2001:db8::1]:5000", "9.9.9.9", "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: tt.remote, Header: http.Header{}}
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.realIp != "" {
+				r.Header.Set("X-Real-IP", tt.realIp)
+			}
+			if got := proxies.ClientIp(r); got != tt.want {
+				t.Errorf("ClientIp() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoteIp(t *testing.T) {
+	tests := map[string]string{
+		"1.2.3.4:5000":       "1.2.3.4",
+		"[2001:db8::1]:5000": "2001:db8::1",
+		"1.2.3.4":            "1.2.3.4",
+	}
+	for addr, want := range tests {
+		if got := RemoteIp(addr); got != want {
+			t.Errorf("RemoteIp(%s) = %s, want %s", addr, got, want)
+		}
+	}
+}
@@ -1,35 +1,66 @@
 package jwts
 
 import (
-	"errors"
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
 	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+// token 的类型，access token 用于 entry，refresh token 只用于换取新的 token
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
 )
 
 type CustomClaims struct {
-	Uid string `json:"uid"`
+	Uid  string `json:"uid"`
+	Type string `json:"type,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenToken 生成Token
-func GenToken(claims *CustomClaims, secret string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+// NewClaims 生成指定类型和有效期的 claims，jti 为随机值，吊销 token 时使用
+func NewClaims(uid, typ string, exp time.Duration) *CustomClaims {
+	now := time.Now()
+	return &CustomClaims{
+		Uid:  uid,
+		Type: typ,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        newTokenId(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(exp)),
+		},
+	}
 }
 
-// ParseToken 解析Token
-func ParseToken(token, secret string) (string, error) {
-	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+// GenToken 使用 Init 加载的当前签名密钥生成Token
+func GenToken(claims *CustomClaims) (string, error) {
+	s, err := current()
 	if err != nil {
 		return "", err
 	}
-	if claims, ok := t.Claims.(jwt.MapClaims); ok && t.Valid {
-		return fmt.Sprintf("%v", claims["uid"]), nil
+	return s.GenToken(claims)
+}
+
+// ParseToken 使用 Init 加载的密钥解析Token，校验签名和有效期后返回 claims
+func ParseToken(token string) (*CustomClaims, error) {
+	s, err := current()
+	if err != nil {
+		return nil, err
 	}
-	return "", errors.New("token not valid")
+	return s.ParseToken(token)
+}
+
+// Remaining token 距离过期的剩余时间，吊销记录只需要保存这么久
+func (c *CustomClaims) Remaining() time.Duration {
+	if c.ExpiresAt == nil {
+		return 0
+	}
+	return time.Until(c.ExpiresAt.Time)
+}
+
+func newTokenId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
 }
@@ -0,0 +1,200 @@
+package jwts
+
+import (
+	"common/config"
+	"common/logs"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"sync"
+)
+
+// key 一个签名密钥，signKey 为空时只能用于验证
+type key struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+// KeySet 签发和验证 token 使用的一组密钥，token 头部的 kid 指明使用哪个密钥
+type KeySet struct {
+	active string
+	keys   map[string]*key // 未退役的密钥
+}
+
+// NewKeySet 根据配置加载密钥，secret 作为 kid 为空的 HS256 密钥
+func NewKeySet(conf config.JwtConf) (*KeySet, error) {
+	s := &KeySet{
+		active: conf.ActiveKid,
+		keys:   make(map[string]*key),
+	}
+	if conf.Secret != "" {
+		s.keys[""] = &key{
+			method:    jwt.SigningMethodHS256,
+			signKey:   []byte(conf.Secret),
+			verifyKey: []byte(conf.Secret),
+		}
+	}
+	for _, v := range conf.Keys {
+		if v.Retired {
+			continue
+		}
+		if v.Kid == "" {
+			return nil, errors.New("jwt key kid is empty")
+		}
+		if _, ok := s.keys[v.Kid]; ok {
+			return nil, fmt.Errorf("jwt key kid duplicate: %s", v.Kid)
+		}
+		k, err := loadKey(v)
+		if err != nil {
+			return nil, fmt.Errorf("load jwt key %s err: %v", v.Kid, err)
+		}
+		s.keys[v.Kid] = k
+	}
+	if _, ok := s.keys[s.active]; !ok {
+		return nil, fmt.Errorf("jwt active key not found: %q", s.active)
+	}
+	return s, nil
+}
+
+// loadKey 根据算法读取密钥，非对称算法配置了私钥时从私钥得到公钥
+func loadKey(conf config.JwtKey) (*key, error) {
+	k := &key{kid: conf.Kid}
+	switch conf.Alg {
+	case "", "HS256":
+		if conf.Secret == "" {
+			return nil, errors.New("secret is empty")
+		}
+		k.method = jwt.SigningMethodHS256
+		k.signKey = []byte(conf.Secret)
+		k.verifyKey = []byte(conf.Secret)
+	case "RS256":
+		k.method = jwt.SigningMethodRS256
+		if conf.PrivateKey != "" {
+			data, err := os.ReadFile(conf.PrivateKey)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey = private
+			k.verifyKey = &private.PublicKey
+		}
+		if conf.PublicKey != "" {
+			data, err := os.ReadFile(conf.PublicKey)
+			if err != nil {
+				return nil, err
+			}
+			if k.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+				return nil, err
+			}
+		}
+	case "EdDSA", "Ed25519":
+		k.method = jwt.SigningMethodEdDSA
+		if conf.PrivateKey != "" {
+			data, err := os.ReadFile(conf.PrivateKey)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey = private
+			k.verifyKey = private.(ed25519.PrivateKey).Public()
+		}
+		if conf.PublicKey != "" {
+			data, err := os.ReadFile(conf.PublicKey)
+			if err != nil {
+				return nil, err
+			}
+			if k.verifyKey, err = jwt.ParseEdPublicKeyFromPEM(data); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported alg: %s", conf.Alg)
+	}
+	if k.verifyKey == nil {
+		return nil, errors.New("publicKey or privateKey is required")
+	}
+	return k, nil
+}
+
+// GenToken 使用当前的签名密钥生成 token，头部带上 kid
+func (s *KeySet) GenToken(claims *CustomClaims) (string, error) {
+	k := s.keys[s.active]
+	if k.signKey == nil {
+		return "", fmt.Errorf("jwt key %s has no private key", k.kid)
+	}
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.kid != "" {
+		token.Header["kid"] = k.kid
+	}
+	return token.SignedString(k.signKey)
+}
+
+// ParseToken 根据 kid 选择密钥验证 token，签名算法必须和密钥一致，校验签名和有效期后返回 claims
+func (s *KeySet) ParseToken(token string) (*CustomClaims, error) {
+	claims := new(CustomClaims)
+	t, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		k, ok := s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid: %q", kid)
+		}
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return k.verifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !t.Valid || claims.Uid == "" {
+		return nil, errors.New("token not valid")
+	}
+	return claims, nil
+}
+
+var (
+	lock   sync.RWMutex
+	keySet *KeySet
+)
+
+// Init 根据 jwt 配置加载密钥，配置文件修改后重新加载，新的配置有误时继续使用旧的密钥
+func Init() error {
+	s, err := NewKeySet(config.Conf.Jwt)
+	if err != nil {
+		return err
+	}
+	lock.Lock()
+	keySet = s
+	lock.Unlock()
+	config.OnChange(func(conf *config.Config) {
+		s, err := NewKeySet(conf.Jwt)
+		if err != nil {
+			logs.Error("reload jwt keys err:%v, keep old keys", err)
+			return
+		}
+		lock.Lock()
+		keySet = s
+		lock.Unlock()
+		logs.Info("jwt keys reloaded, active kid=%q", conf.Jwt.ActiveKid)
+	})
+	return nil
+}
+
+func current() (*KeySet, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	if keySet == nil {
+		return nil, errors.New("jwt keys not initialized")
+	}
+	return keySet, nil
+}
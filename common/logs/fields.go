@@ -0,0 +1,56 @@
+package logs
+
+import (
+	"fmt"
+	"github.com/charmbracelet/log"
+)
+
+// 常用的日志字段，同一个请求在 connector 和节点上的日志可以按这些字段关联起来
+const (
+	Uid      = "uid"
+	Cid      = "cid"
+	Route    = "route"
+	RoomId   = "roomId"
+	ServerId = "serverId"
+	TraceId  = "traceId"
+)
+
+// Entry 带有 key/value 字段的日志，json 格式输出时字段作为独立的 key
+type Entry struct {
+	keyvals []any
+}
+
+// With 创建带字段的日志，keyvals 为 key、value 交替的列表，如 logs.With(logs.Uid, uid)
+func With(keyvals ...any) *Entry {
+	return &Entry{keyvals: keyvals}
+}
+
+// With 在当前字段的基础上追加字段
+func (e *Entry) With(keyvals ...any) *Entry {
+	return &Entry{keyvals: append(append([]any{}, e.keyvals...), keyvals...)}
+}
+
+// log 每次都使用全局 logger 输出，运行时修改的日志级别对已创建的 Entry 同样生效
+func (e *Entry) log(level log.Level, format string, values ...any) {
+	if logger.GetLevel() > level {
+		return
+	}
+	msg := format
+	if len(values) > 0 {
+		msg = fmt.Sprintf(format, values...)
+	}
+	logger.Log(level, msg, e.keyvals...)
+}
+
+func (e *Entry) Debug(format string, values ...any) {
+	e.log(log.DebugLevel, format, values...)
+}
+func (e *Entry) Info(format string, values ...any) {
+	e.log(log.InfoLevel, format, values...)
+}
+func (e *Entry) Warn(format string, values ...any) {
+	e.log(log.WarnLevel, format, values...)
+}
+func (e *Entry) Error(format string, values ...any) {
+	e.log(log.ErrorLevel, format, values...)
+}
@@ -3,24 +3,52 @@ package logs
 import (
 	"common/config"
 	"github.com/charmbracelet/log"
-	"os"
+	"strings"
 	"time"
 )
 
 var logger *log.Logger
 
 func InitLog(appName string) {
-	logger = log.New(os.Stderr)
-	if config.Conf.Log.Level == "DEBUG" {
-		log.SetLevel(log.DebugLevel)
-	} else {
-		log.SetLevel(log.InfoLevel)
+	logger = log.New(output(config.Conf.Log))
+	SetLevel(config.Conf.Log.Level)
+	if config.Conf.Log.Format == "json" {
+		logger.SetFormatter(log.JSONFormatter)
 	}
 	logger.SetPrefix(appName)
 	logger.SetReportTimestamp(true)
 	logger.SetTimeFormat(time.DateTime)
+	// 修改 application.yml 中的日志级别后立即生效
+	config.OnChange(func(conf *config.Config) {
+		SetLevel(conf.Log.Level)
+	})
+	// 配置文件修改后解析失败时写入日志，不再只打印到 stderr
+	config.OnReloadError(func(err error) {
+		Error("config file changed, unmarshal err:%v, keep old config", err)
+	})
+}
+
+// SetLevel 运行时修改日志级别，支持 DEBUG、INFO、WARN、ERROR，其它值按 INFO 处理
+func SetLevel(level string) {
+	switch strings.ToUpper(level) {
+	case "DEBUG":
+		logger.SetLevel(log.DebugLevel)
+	case "WARN":
+		logger.SetLevel(log.WarnLevel)
+	case "ERROR":
+		logger.SetLevel(log.ErrorLevel)
+	default:
+		logger.SetLevel(log.InfoLevel)
+	}
 }
 
+func Debug(format string, values ...any) {
+	if len(values) == 0 {
+		logger.Debug(format)
+	} else {
+		logger.Debugf(format, values...)
+	}
+}
 func Warn(format string, values ...any) {
 	if len(values) == 0 {
 		logger.Warn(format)
@@ -0,0 +1,39 @@
+package logs
+
+import (
+	"common/config"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+	"os"
+	"time"
+)
+
+// output 根据配置选择日志输出，配置了文件时按大小和时间切割
+func output(conf config.LogConf) io.Writer {
+	if conf.File == "" {
+		return os.Stderr
+	}
+	rotator := &lumberjack.Logger{
+		Filename:   conf.File,
+		MaxSize:    conf.MaxSize,
+		MaxAge:     conf.MaxAge,
+		MaxBackups: conf.MaxBackups,
+		LocalTime:  true,
+		Compress:   conf.Compress,
+	}
+	if conf.RotateHours > 0 {
+		go rotateEvery(rotator, time.Duration(conf.RotateHours)*time.Hour)
+	}
+	return rotator
+}
+
+// rotateEvery 定时切割日志文件
+func rotateEvery(rotator *lumberjack.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := rotator.Rotate(); err != nil {
+			Error("rotate log file err:%v", err)
+		}
+	}
+}
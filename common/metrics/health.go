@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Check 健康检查函数，返回 nil 表示正常
+type Check func(ctx context.Context) error
+
+// checkTimeout 单个检查的超时时间
+const checkTimeout = 2 * time.Second
+
+var (
+	checksLock sync.RWMutex
+	checks     = make(map[string]Check)
+)
+
+// RegisterCheck 注册就绪检查（数据库、nats、etcd 等依赖以及服务自身的状态），同名检查会被覆盖
+func RegisterCheck(name string, check Check) {
+	checksLock.Lock()
+	defer checksLock.Unlock()
+	checks[name] = check
+}
+
+// HealthResult 检查结果，Checks 中正常的检查为 ok，异常的为错误信息
+type HealthResult struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// RunChecks 执行指定名称的检查，全部正常时返回 nil，否则返回第一个失败的检查，没有注册的名称会被忽略
+func RunChecks(ctx context.Context, names ...string) error {
+	result, ready := runChecks(ctx, names...)
+	if ready {
+		return nil
+	}
+	for _, name := range names {
+		if msg, ok := result.Checks[name]; ok && msg != "ok" {
+			return fmt.Errorf("%s: %s", name, msg)
+		}
+	}
+	return errors.New(result.Status)
+}
+
+// runChecks 并发执行检查，only 为空时执行所有检查
+func runChecks(ctx context.Context, only ...string) (HealthResult, bool) {
+	checksLock.RLock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		if len(only) == 0 || slices.Contains(only, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	fns := make([]Check, len(names))
+	for i, name := range names {
+		fns[i] = checks[name]
+	}
+	checksLock.RUnlock()
+
+	errs := make([]error, len(fns))
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func(i int, fn Check) {
+			defer wg.Done()
+			c, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			errs[i] = fn(c)
+		}(i, fn)
+	}
+	wg.Wait()
+
+	result := HealthResult{Status: "ok", Checks: make(map[string]string, len(names))}
+	ready := true
+	for i, name := range names {
+		if errs[i] != nil {
+			ready = false
+			result.Status = "fail"
+			result.Checks[name] = errs[i].Error()
+			continue
+		}
+		result.Checks[name] = "ok"
+	}
+	return result, ready
+}
+
+// healthz 存活检查，进程能响应就返回 200，同时附带各项检查的结果
+func healthz(w http.ResponseWriter, r *http.Request) {
+	result, _ := runChecks(r.Context())
+	writeHealth(w, http.StatusOK, result)
+}
+
+// readyz 就绪检查，任何一项检查失败都返回 503，编排系统据此摘除实例
+func readyz(w http.ResponseWriter, r *http.Request) {
+	result, ready := runChecks(r.Context())
+	code := http.StatusOK
+	if !ready {
+		code = http.StatusServiceUnavailable
+	}
+	writeHealth(w, code, result)
+}
+
+func writeHealth(w http.ResponseWriter, code int, result HealthResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(result)
+}
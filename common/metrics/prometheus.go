@@ -0,0 +1,82 @@
+package metrics
+
+// Prometheus 指标，通过监控端口的 /metrics 以 Prometheus 文本格式输出
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"time"
+)
+
+const namespace = "msqp"
+
+var (
+	// Connections connector 当前打开的 websocket 连接数
+	Connections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "connections_open",
+		Help:      "Number of open websocket connections.",
+	})
+	// PacketsIn connector 收到的数据包数，按包类型区分
+	PacketsIn = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "packets_in_total",
+		Help:      "Packets received from clients by package type.",
+	}, []string{"type"})
+	// PacketsOut connector 发出的数据包数，按包类型区分
+	PacketsOut = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "packets_out_total",
+		Help:      "Packets sent to clients by package type.",
+	}, []string{"type"})
+	// Requests 每个路由的请求数
+	Requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Handled requests by route.",
+	}, []string{"route"})
+	// RequestDuration 每个路由的处理耗时
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Request handling latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route"})
+	// NatsPublishErrors nats 发送失败的次数
+	NatsPublishErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nats_publish_errors_total",
+		Help:      "Failed NATS publishes.",
+	})
+	// ActiveRooms game 节点上当前的房间数，按游戏类型区分
+	ActiveRooms = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rooms_active",
+		Help:      "Active rooms by game type.",
+	}, []string{"gameType"})
+	// ActivePlayers game 节点上当前在房间中的玩家数，按游戏类型区分
+	ActivePlayers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "players_active",
+		Help:      "Players in rooms by game type.",
+	}, []string{"gameType"})
+	// GrpcCalls grpc 服务端处理的调用数，按方法和状态码区分
+	GrpcCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_calls_total",
+		Help:      "gRPC calls handled by method and status code.",
+	}, []string{"method", "code"})
+	// GrpcDuration grpc 服务端的处理耗时
+	GrpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_duration_seconds",
+		Help:      "gRPC call latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// ObserveRequest 记录一次路由请求，作为 node.Metrics、net.Metrics 中间件的 observe 使用
+func ObserveRequest(route string, cost time.Duration) {
+	Requests.WithLabelValues(route).Inc()
+	RequestDuration.WithLabelValues(route).Observe(cost.Seconds())
+}
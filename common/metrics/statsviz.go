@@ -2,12 +2,23 @@ package metrics
 
 import (
 	"github.com/arl/statsviz"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"net/http"
 )
 
-// Serve 启动可视化监听指标服务 可视化图表 /debug/statsviz
+// mux 监控端口上的路由，其它模块可以通过 Handle 挂载自己的接口
+var mux = http.NewServeMux()
+
+// Handle 在监控端口上注册接口，可以在 Serve 之前或之后调用
+func Handle(pattern string, handler http.Handler) {
+	mux.Handle(pattern, handler)
+}
+
+// Serve 启动可视化监听指标服务 可视化图表 /debug/statsviz，健康检查 /healthz、/readyz，Prometheus 指标 /metrics
 func Serve(addr string) error {
-	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", healthz)
+	mux.HandleFunc("/readyz", readyz)
 	err := statsviz.Register(mux)
 	if err != nil {
 		return err
@@ -0,0 +1,111 @@
+package tracing
+
+// 请求链路追踪：connector 收到客户端请求时生成 trace，经 remote.Msg 传到节点，
+// 再随推送、grpc 调用继续传递，span 兼容 OpenTelemetry，可以输出到控制台或文件
+
+import (
+	"common/config"
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"os"
+)
+
+const tracerName = "msqp"
+
+var (
+	provider   *sdktrace.TracerProvider
+	propagator = propagation.TraceContext{}
+	output     io.Closer
+)
+
+// Init 初始化链路追踪，始终生成 trace id 用于日志关联，配置了 exporter 时才输出 span：
+// stdout 输出到控制台，file 输出到 trace.file 指定的文件
+func Init(serviceName string) error {
+	conf := config.Conf.Trace
+	ratio := conf.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	}
+	var writer io.Writer
+	switch conf.Exporter {
+	case "stdout":
+		writer = os.Stdout
+	case "file":
+		file, err := os.OpenFile(conf.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		writer = file
+		output = file
+	}
+	if writer != nil {
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(writer))
+		if err != nil {
+			return err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+	return nil
+}
+
+// Close 输出剩余的 span 并关闭输出文件
+func Close(ctx context.Context) {
+	if provider != nil {
+		_ = provider.Shutdown(ctx)
+	}
+	if output != nil {
+		_ = output.Close()
+	}
+}
+
+// Start 以 ctx 中的 span 为父 span 开始一个新的 span
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// Inject 将 ctx 中的 trace 信息写入 map，随 remote.Msg 等消息传递
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract 从 Inject 生成的 map 中还原 trace 信息
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// TraceId 返回 ctx 中的 trace id，没有时返回空
+func TraceId(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// ExtractHeader 从 http 请求头中还原 trace 信息（W3C traceparent）
+func ExtractHeader(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
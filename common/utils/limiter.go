@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter 按 key 计数的固定窗口限流器，每个 key 在 per 时间窗口内最多放行 limit 次
+type Limiter struct {
+	sync.Mutex
+	limit   int
+	per     time.Duration
+	windows map[string]*limitWindow
+}
+
+type limitWindow struct {
+	start time.Time
+	count int
+}
+
+func NewLimiter(limit int, per time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		per:     per,
+		windows: make(map[string]*limitWindow),
+	}
+}
+
+// Allow 判断 key 在当前窗口内是否还能通过
+func (l *Limiter) Allow(key string) bool {
+	l.Lock()
+	defer l.Unlock()
+	now := time.Now()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.per {
+		if len(l.windows) > 10000 {
+			l.cleanup(now)
+		}
+		l.windows[key] = &limitWindow{start: now, count: 1}
+		return true
+	}
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// cleanup 清理已经过期的窗口，避免 key 无限增长
+func (l *Limiter) cleanup(now time.Time) {
+	for k, w := range l.windows {
+		if now.Sub(w.start) >= l.per {
+			delete(l.windows, k)
+		}
+	}
+}
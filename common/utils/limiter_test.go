@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		keys  []string
+		want  []bool
+	}{
+		{"within limit", 3, []string{"a", "a", "a"}, []bool{true, true, true}},
+		{"over limit", 2, []string{"a", "a", "a", "a"}, []bool{true, true, false, false}},
+		{"keys are independent", 1, []string{"a", "b", "a", "b"}, []bool{true, true, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(tt.limit, time.Minute)
+			for i, key := range tt.keys {
+				if got := l.Allow(key); got != tt.want[i] {
+					t.Errorf("Allow(%s) #%d = %v, want %v", key, i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterWindowRollover(t *testing.T) {
+	per := 50 * time.Millisecond
+	l := NewLimiter(2, per)
+	if !l.Allow("a") || !l.Allow("a") {
+		t.Fatal("Allow() = false within limit")
+	}
+	if l.Allow("a") {
+		t.Fatal("Allow() = true over limit")
+	}
+	time.Sleep(per + 10*time.Millisecond)
+	// 新的窗口重新计数
+	if !l.Allow("a") || !l.Allow("a") {
+		t.Fatal("Allow() = false after window rollover")
+	}
+	if l.Allow("a") {
+		t.Fatal("Allow() = true over limit in new window")
+	}
+}
+
+func TestLimiterCleanup(t *testing.T) {
+	per := 50 * time.Millisecond
+	l := NewLimiter(1, per)
+	for i := 0; i <= 10000; i++ {
+		l.Allow(strconv.Itoa(i))
+	}
+	time.Sleep(per + 10*time.Millisecond)
+	// 超过 10000 个 key 时，新窗口创建前清理已经过期的窗口
+	l.Allow("new")
+	if len(l.windows) != 1 {
+		t.Errorf("windows = %d after cleanup, want 1", len(l.windows))
+	}
+}
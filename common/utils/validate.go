@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"github.com/go-playground/validator/v10"
+	"reflect"
+)
+
+var validate = validator.New()
+
+// Validate 按结构体的 validate 标签校验请求参数，非结构体不做校验
+func Validate(v any) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return validate.Struct(v)
+}
+
+// IsNil 判断接口值或其中的指针、map、slice 是否为 nil
+func IsNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// WaitUntil 每隔 interval 检查一次 done，满足时返回 nil，ctx 结束时返回 ctx 的错误
+func WaitUntil(ctx context.Context, interval time.Duration, done func() bool) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for !done() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -2,13 +2,23 @@ package app
 
 import (
 	"common/config"
+	"common/ipfilter"
+	"common/jwts"
 	"common/logs"
+	"common/metrics"
+	"common/tracing"
 	"connector/route"
 	"context"
+	"core/dao"
 	"core/repo"
+	"core/rpc"
+	"errors"
 	"framework/connector"
+	"framework/msError"
+	"framework/net"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -17,25 +27,66 @@ import (
 func Run(ctx context.Context, serverId string) error {
 	// 初始化日志库
 	logs.InitLog(config.Conf.AppName)
+	// 初始化链路追踪
+	if err := tracing.Init(config.Conf.AppName); err != nil {
+		logs.Error("init tracing err:%v", err)
+	}
+	// 加载签发和验证 token 的密钥，配置修改后自动重新加载
+	if err := jwts.Init(); err != nil {
+		logs.Fatal("init jwt keys err:%v", err)
+	}
+	// 加载 IP 黑白名单，配置修改后自动重新加载
+	if err := ipfilter.Init(); err != nil {
+		logs.Fatal("init ip filter err:%v", err)
+	}
 
 	// 定义一个退出函数
-	exit := func() {}
+	exit := func(ctx context.Context) {}
 	go func() {
 		// 获取默认的连接器实例
 		c := connector.Default()
-		exit = c.Close
+		// 停止时先取消踢人的订阅，关闭 redis 的 pubsub，再等待连接排空
+		kickCtx, stopKick := context.WithCancel(context.Background())
+		exit = func(ctx context.Context) {
+			stopKick()
+			c.Drain(ctx)
+		}
 		manager := repo.New()
+		manager.RegisterChecks()
+		// 初始化 user 服务的 grpc 客户端，用户数据统一通过 user 服务读写
+		rpc.Init()
 		// 注册路由处理器
-		c.RegisterHandler(route.Register(manager))
+		route.Register(c, manager)
+		// 登出、吊销 token 时，踢下线该用户在当前 connector 上的连接
+		dao.NewTokenDao(manager).SubscribeKick(kickCtx, func(msg dao.KickMsg) {
+			c.KickUser(msg.Uid, msError.NewError(msg.Code, errors.New(msg.Reason)))
+		})
+		// 会话数据保存到 redis，connector 重启后玩家重新 entry 时恢复
+		c.SetSessionStore(dao.NewSessionDao(manager, time.Duration(config.Conf.Session.Ttl)*time.Second))
+		// 拒绝黑名单中的 IP，并限制每个 IP 的连接数，修改配置后对新的连接生效
+		var maxConnPerIp atomic.Int64
+		maxConnPerIp.Store(int64(config.Conf.Security.MaxConnPerIp))
+		config.OnChange(func(conf *config.Config) {
+			maxConnPerIp.Store(int64(conf.Security.MaxConnPerIp))
+		})
+		c.SetIpLimit(ipfilter.Allow, func() int {
+			return int(maxConnPerIp.Load())
+		})
+		// 只信任 security.trustedProxies 中的代理传来的 X-Forwarded-For、X-Real-IP
+		c.SetClientIp(ipfilter.ClientIp)
+		// 注册中间件：panic恢复、请求日志、监控指标，entry 限制同一连接的尝试次数
+		c.Use(net.Recovery(), net.Logging(), net.Metrics(metrics.ObserveRequest))
+		c.UseRoute("entryHandler.entry", net.RateLimit(5, time.Minute))
 		// 启动连接器
 		c.Run(serverId)
 	}()
 
-	// 优雅启动与停止
+	// 优雅启动与停止：在超时时间内等待正在处理的请求和推送完成
 	stop := func() {
-		exit()
-		// 给出时间让程序停止
-		time.Sleep(3 * time.Second)
+		drainCtx, cancel := context.WithTimeout(context.Background(), config.Conf.Shutdown.GetTimeout())
+		defer cancel()
+		exit(drainCtx)
+		tracing.Close(context.Background())
 		logs.Info("stop app finish")
 	}
 
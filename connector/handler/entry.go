@@ -3,56 +3,85 @@ package handler
 import (
 	"common"
 	"common/biz"
-	"common/config"
 	"common/jwts"
 	"common/logs"
 	"connector/models/request"
 	"context"
+	"core/dao"
 	"core/repo"
-	"core/service"
-	"encoding/json"
+	"core/rpc"
 	"framework/game"
+	"framework/msError"
 	"framework/net"
+	"user/pb"
 )
 
 // EntryHandler 结构体定义了一个处理入口的处理器
 type EntryHandler struct {
-	userService *service.UserService
+	tokenDao *dao.TokenDao
 }
 
 // Entry 方法处理进入的会话和消息体
-func (h *EntryHandler) Entry(session *net.Session, body []byte) (any, error) {
+func (h *EntryHandler) Entry(ctx context.Context, session *net.Session, req *request.EntryReq) (any, error) {
 	logs.Info("=================Entry Start============================")
-	logs.Info("Entry Handler Entry %v", string(body))
+	logs.Info("Entry Handler Entry %v", req)
 	logs.Info("=================Entry End  ============================")
-	var req request.EntryReq
-	err := json.Unmarshal(body, &req)
-	if err != nil {
-		return common.Fail(biz.RequestDataError), nil
+	// 校验Token, 解析出来一个uid，只接受 access token
+	claims, err := jwts.ParseToken(req.Token)
+	if err != nil || claims.Type != jwts.AccessToken {
+		logs.Error("parse token err %v", err)
+		return nil, biz.TokenInfoError
 	}
-
-	// 校验Token, 解析出来一个uid
-	uid, err := jwts.ParseToken(req.Token, config.Conf.Jwt.Secret)
+	uid := claims.Uid
+	// 已经登出或被吊销的 token 不能再进入
+	revoked, err := h.tokenDao.IsRevoked(ctx, claims.ID)
 	if err != nil {
-		logs.Error("parse token err %v", err)
-		return common.Fail(biz.TokenInfoError), nil
+		logs.Error("check token revoked err:%v, uid=%s", err, uid)
+		return nil, biz.SqlError
+	}
+	if revoked {
+		return nil, biz.TokenInfoError
 	}
 
-	// 根据uid在mongo中查询用户，如果用户不存在，生成一个用户
-	user, err := h.userService.FindAndSaveUserByUid(context.TODO(), uid, req.UserInfo)
+	// 通过 user 服务查询用户，如果用户不存在，生成一个用户，同时记录登录 IP 用于审计
+	res, err := rpc.UserClient.GetUser(ctx, &pb.GetUserParams{
+		Uid:      uid,
+		Create:   true,
+		Nickname: req.UserInfo.Nickname,
+		Avatar:   req.UserInfo.Avatar,
+		Sex:      int32(req.UserInfo.Sex),
+		LoginIp:  session.Ip,
+	})
 	if err != nil {
-		return common.Fail(biz.SqlError), nil
+		logs.Error("get user err:%v, uid=%s", err, uid)
+		return nil, msError.AsError(err, biz.SqlError)
 	}
-	session.Uid = uid
+	user := rpc.ToEntityUser(res.User)
+	// 冻结的帐号不能进入，冻结到期后自动恢复
+	if user.IsBlocked() {
+		logs.Warn("blocked account entry, uid=%s, reason=%s", uid, user.BlockReason)
+		return nil, biz.BlockedAccount
+	}
+	// 绑定用户并恢复上一次的会话数据（所在房间、路由绑定的节点等）
+	session.Bind(uid)
 	return common.S(map[string]any{
 		"userInfo": user,
 		"config":   game.Conf.GetFromGameConfig(),
 	}), nil
 }
 
+// Logout 用户主动登出，清空会话数据，之后重新 entry 不再恢复
+func (h *EntryHandler) Logout(session *net.Session, body []byte) (any, error) {
+	if session.GetUid() == "" {
+		return common.Fail(biz.InvalidUsers), nil
+	}
+	session.Clear()
+	return common.S(nil), nil
+}
+
 // NewEntryHandler 创建并返回一个新的 EntryHandler 实例
 func NewEntryHandler(r *repo.Manager) *EntryHandler {
 	return &EntryHandler{
-		userService: service.NewUserService(r),
+		tokenDao: dao.NewTokenDao(r),
 	}
 }
@@ -1,7 +1,7 @@
 package request
 
 type EntryReq struct {
-	Token    string   `json:"token"`
+	Token    string   `json:"token" validate:"required"`
 	UserInfo UserInfo `json:"userInfo"`
 }
 
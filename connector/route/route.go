@@ -3,15 +3,17 @@ package route
 import (
 	"connector/handler"
 	"core/repo"
+	"framework/connector"
 	"framework/net"
 )
 
-// Register 函数注册所有的处理器并返回一个 LogicHandler
-func Register(r *repo.Manager) net.LogicHandler {
-	handlers := make(net.LogicHandler)
+// Register 函数按处理器分组，将所有的处理器注册到 connector 上
+func Register(c *connector.Connector, r *repo.Manager) {
 	entryHandler := handler.NewEntryHandler(r) // 创建一个新的 EntryHandler 实例
 
-	// 将 EntryHandler 的 Entry 方法注册到 handlers 中
-	handlers["entryHandler.entry"] = entryHandler.Entry
-	return handlers
+	// 注册 entryHandler 的 entry、logout 方法
+	c.RegisterGroup("entryHandler", net.LogicHandler{
+		"entry":  net.Typed(entryHandler.Entry),
+		"logout": entryHandler.Logout,
+	})
 }
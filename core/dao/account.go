@@ -4,6 +4,11 @@ import (
 	"context"
 	"core/models/entity"
 	"core/repo"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // 实现了 AccountDao 数据访问对象（DAO），用于与数据库交互，
@@ -27,6 +32,156 @@ func (d AccountDao) SaveAccount(ctx context.Context, ac *entity.Account) error {
 	return nil
 }
 
+// EnsureIndexes 创建账号表的唯一索引：账号、手机号、微信账号不能重复，同一设备只能有一个游客账号，
+// 字段为空的文档不参与索引。并发注册、绑定时以索引为准，重复时 SaveAccount、BindAccount 返回 IsDuplicateKey 的错误
+func (d AccountDao) EnsureIndexes(ctx context.Context) error {
+	table := d.repo.Mongo.Db.Collection("account")
+	notEmpty := func(field string) bson.D {
+		return bson.D{{Key: field, Value: bson.M{"$gt": ""}}}
+	}
+	_, err := table.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "account", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(notEmpty("account")),
+		},
+		{
+			Keys:    bson.D{{Key: "phoneAccount", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(notEmpty("phoneAccount")),
+		},
+		{
+			Keys:    bson.D{{Key: "wxAccount", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(notEmpty("wxAccount")),
+		},
+		{
+			// 游客绑定后不再是游客，同一设备可以再创建新的游客账号
+			Keys: bson.D{{Key: "deviceId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(
+				append(notEmpty("deviceId"), bson.E{Key: "guest", Value: true})),
+		},
+	})
+	return err
+}
+
+// uniqueFields 唯一索引的字段和参与索引的额外条件，和 EnsureIndexes 保持一致
+var uniqueFields = []struct {
+	field  string
+	filter bson.M
+}{
+	{field: "account"},
+	{field: "phoneAccount"},
+	{field: "wxAccount"},
+	{field: "deviceId", filter: bson.M{"guest": true}},
+}
+
+// ReconcileDuplicates 整理创建唯一索引前已经存在的重复数据：旧版本的微信注册每次都会新建账号，
+// 并发注册也可能产生重复的账号、手机号。同一个值只保留最早创建的账号（查询时原本返回的就是它），
+// 其它账号的该字段清空，原来的值移到 <字段>Dup 中，方便人工核对和合并，返回整理的账号数
+func (d AccountDao) ReconcileDuplicates(ctx context.Context) (int64, error) {
+	table := d.repo.Mongo.Db.Collection("account")
+	var moved int64
+	for _, u := range uniqueFields {
+		match := bson.M{u.field: bson.M{"$gt": ""}}
+		for k, v := range u.filter {
+			match[k] = v
+		}
+		cursor, err := table.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: match}},
+			{{Key: "$sort", Value: bson.D{{Key: "createTime", Value: 1}, {Key: "_id", Value: 1}}}},
+			{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: "$" + u.field},
+				{Key: "ids", Value: bson.M{"$push": "$_id"}},
+			}}},
+			{{Key: "$match", Value: bson.M{"ids.1": bson.M{"$exists": true}}}},
+		}, options.Aggregate().SetAllowDiskUse(true))
+		if err != nil {
+			return moved, err
+		}
+		var groups []struct {
+			Value string               `bson:"_id"`
+			Ids   []primitive.ObjectID `bson:"ids"`
+		}
+		if err := cursor.All(ctx, &groups); err != nil {
+			return moved, err
+		}
+		for _, g := range groups {
+			result, err := table.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": g.Ids[1:]}}, bson.M{
+				"$set": bson.M{
+					u.field:         "",
+					u.field + "Dup": g.Value,
+				},
+			})
+			if err != nil {
+				return moved, err
+			}
+			moved += result.ModifiedCount
+		}
+	}
+	return moved, nil
+}
+
+// IsDuplicateKey 是否因为违反唯一索引导致保存失败
+func IsDuplicateKey(err error) bool {
+	return mongo.IsDuplicateKeyError(err)
+}
+
+// FindAccount 根据账号查找账号信息，账号不存在时返回 nil
+func (d AccountDao) FindAccount(ctx context.Context, account string) (*entity.Account, error) {
+	return d.findOne(ctx, bson.M{"account": account})
+}
+
+// FindAccountByPhone 根据绑定的手机号查找账号信息，账号不存在时返回 nil
+func (d AccountDao) FindAccountByPhone(ctx context.Context, phone string) (*entity.Account, error) {
+	return d.findOne(ctx, bson.M{"phoneAccount": phone})
+}
+
+// FindAccountByWx 根据绑定的微信账号查找账号信息，账号不存在时返回 nil
+func (d AccountDao) FindAccountByWx(ctx context.Context, wxAccount string) (*entity.Account, error) {
+	return d.findOne(ctx, bson.M{"wxAccount": wxAccount})
+}
+
+// FindGuestAccount 根据设备 id 查找还没有绑定的游客账号，不存在时返回 nil
+func (d AccountDao) FindGuestAccount(ctx context.Context, deviceId string) (*entity.Account, error) {
+	return d.findOne(ctx, bson.M{"deviceId": deviceId, "guest": true})
+}
+
+// FindAccountByUid 根据 uid 查找账号信息，账号不存在时返回 nil
+func (d AccountDao) FindAccountByUid(ctx context.Context, uid string) (*entity.Account, error) {
+	return d.findOne(ctx, bson.M{"uid": uid})
+}
+
+// BindAccount 给账号绑定手机号或微信，field 为 phoneAccount 或 wxAccount，
+// 只有该字段还没有绑定时才会修改，返回是否修改成功
+func (d AccountDao) BindAccount(ctx context.Context, uid, field, value string) (bool, error) {
+	table := d.repo.Mongo.Db.Collection("account")
+	result, err := table.UpdateOne(ctx, bson.M{
+		"uid": uid,
+		field: bson.M{"$in": bson.A{"", nil}},
+	}, bson.M{
+		"$set": bson.M{
+			field:   value,
+			"guest": false,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// findOne 按条件查找一个账号，不存在时返回 nil
+func (d AccountDao) findOne(ctx context.Context, filter bson.M) (*entity.Account, error) {
+	table := d.repo.Mongo.Db.Collection("account")
+	ac := new(entity.Account)
+	err := table.FindOne(ctx, filter).Decode(ac)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ac, nil
+}
+
 // NewAccountDao 创建新的账号DAO实例
 func NewAccountDao(m *repo.Manager) *AccountDao {
 	return &AccountDao{
@@ -0,0 +1,71 @@
+package dao
+
+import (
+	"context"
+	"core/repo"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const SessionRedisKey = "session"
+
+// SessionDao connector 会话数据在 redis 中的存储，按 uid 保存并设置过期时间
+type SessionDao struct {
+	repo *repo.Manager
+	ttl  time.Duration
+}
+
+func (d *SessionDao) key(uid string) string {
+	return Predix + ":" + SessionRedisKey + ":" + uid
+}
+
+// Load 读取用户的会话数据，不存在时返回 nil
+func (d *SessionDao) Load(ctx context.Context, uid string) (map[string]any, error) {
+	var value string
+	var err error
+	if d.repo.Redis.Client != nil {
+		value, err = d.repo.Redis.Client.Get(ctx, d.key(uid)).Result()
+	} else {
+		value, err = d.repo.Redis.ClusterClient.Get(ctx, d.key(uid)).Result()
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]any)
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Save 保存用户的会话数据，每次保存都会刷新过期时间
+func (d *SessionDao) Save(ctx context.Context, uid string, data map[string]any) error {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if d.repo.Redis.Client != nil {
+		return d.repo.Redis.Client.Set(ctx, d.key(uid), value, d.ttl).Err()
+	}
+	return d.repo.Redis.ClusterClient.Set(ctx, d.key(uid), value, d.ttl).Err()
+}
+
+// Delete 删除用户的会话数据
+func (d *SessionDao) Delete(ctx context.Context, uid string) error {
+	if d.repo.Redis.Client != nil {
+		return d.repo.Redis.Client.Del(ctx, d.key(uid)).Err()
+	}
+	return d.repo.Redis.ClusterClient.Del(ctx, d.key(uid)).Err()
+}
+
+func NewSessionDao(m *repo.Manager, ttl time.Duration) *SessionDao {
+	return &SessionDao{
+		repo: m,
+		ttl:  ttl,
+	}
+}
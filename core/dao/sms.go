@@ -0,0 +1,113 @@
+package dao
+
+import (
+	"context"
+	"core/repo"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	SmsCodeRedisKey  = "smsCode"
+	SmsLimitRedisKey = "smsLimit"
+	SmsFailRedisKey  = "smsFail"
+)
+
+// SmsDao 短信验证码在 redis 中的存储，验证码和发送间隔都依赖 key 的过期时间
+type SmsDao struct {
+	repo     *repo.Manager
+	ttl      time.Duration // 验证码的有效期
+	interval time.Duration // 同一手机号两次发送的最小间隔
+}
+
+func (d *SmsDao) key(prefix, phone string) string {
+	return Predix + ":" + prefix + ":" + phone
+}
+
+// Acquire 占用手机号的发送间隔，间隔内已经发送过时返回 false
+func (d *SmsDao) Acquire(ctx context.Context, phone string) (bool, error) {
+	if d.repo.Redis.Client != nil {
+		return d.repo.Redis.Client.SetNX(ctx, d.key(SmsLimitRedisKey, phone), 1, d.interval).Result()
+	}
+	return d.repo.Redis.ClusterClient.SetNX(ctx, d.key(SmsLimitRedisKey, phone), 1, d.interval).Result()
+}
+
+// Release 发送失败时释放发送间隔，允许立即重新发送
+func (d *SmsDao) Release(ctx context.Context, phone string) error {
+	if d.repo.Redis.Client != nil {
+		return d.repo.Redis.Client.Del(ctx, d.key(SmsLimitRedisKey, phone)).Err()
+	}
+	return d.repo.Redis.ClusterClient.Del(ctx, d.key(SmsLimitRedisKey, phone)).Err()
+}
+
+// SaveCode 保存验证码，重新发送会覆盖之前的验证码，并清空错误次数
+func (d *SmsDao) SaveCode(ctx context.Context, phone, code string) error {
+	if d.repo.Redis.Client != nil {
+		if err := d.repo.Redis.Client.Del(ctx, d.key(SmsFailRedisKey, phone)).Err(); err != nil {
+			return err
+		}
+		return d.repo.Redis.Client.Set(ctx, d.key(SmsCodeRedisKey, phone), code, d.ttl).Err()
+	}
+	if err := d.repo.Redis.ClusterClient.Del(ctx, d.key(SmsFailRedisKey, phone)).Err(); err != nil {
+		return err
+	}
+	return d.repo.Redis.ClusterClient.Set(ctx, d.key(SmsCodeRedisKey, phone), code, d.ttl).Err()
+}
+
+// IncrFail 验证码错误次数加一，返回当前的错误次数，和验证码同时过期
+func (d *SmsDao) IncrFail(ctx context.Context, phone string) (int64, error) {
+	key := d.key(SmsFailRedisKey, phone)
+	var count int64
+	var err error
+	if d.repo.Redis.Client != nil {
+		count, err = d.repo.Redis.Client.Incr(ctx, key).Result()
+		if err == nil && count == 1 {
+			err = d.repo.Redis.Client.Expire(ctx, key, d.ttl).Err()
+		}
+	} else {
+		count, err = d.repo.Redis.ClusterClient.Incr(ctx, key).Result()
+		if err == nil && count == 1 {
+			err = d.repo.Redis.ClusterClient.Expire(ctx, key, d.ttl).Err()
+		}
+	}
+	return count, err
+}
+
+// GetCode 读取验证码，不存在或已过期时返回空字符串
+func (d *SmsDao) GetCode(ctx context.Context, phone string) (string, error) {
+	var code string
+	var err error
+	if d.repo.Redis.Client != nil {
+		code, err = d.repo.Redis.Client.Get(ctx, d.key(SmsCodeRedisKey, phone)).Result()
+	} else {
+		code, err = d.repo.Redis.ClusterClient.Get(ctx, d.key(SmsCodeRedisKey, phone)).Result()
+	}
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return code, err
+}
+
+// DeleteCode 验证码使用后删除，同一个验证码只能使用一次
+func (d *SmsDao) DeleteCode(ctx context.Context, phone string) error {
+	if d.repo.Redis.Client != nil {
+		return d.repo.Redis.Client.Del(ctx, d.key(SmsCodeRedisKey, phone)).Err()
+	}
+	return d.repo.Redis.ClusterClient.Del(ctx, d.key(SmsCodeRedisKey, phone)).Err()
+}
+
+// NewSmsDao 未配置有效期和发送间隔时分别使用 5 分钟和 1 分钟，避免 key 永不过期
+func NewSmsDao(m *repo.Manager, ttl, interval time.Duration) *SmsDao {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &SmsDao{
+		repo:     m,
+		ttl:      ttl,
+		interval: interval,
+	}
+}
@@ -0,0 +1,102 @@
+package dao
+
+import (
+	"common/logs"
+	"context"
+	"core/repo"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	RevokedTokenRedisKey = "revokedToken"
+	KickRedisChannel     = "kick"
+)
+
+// KickMsg 通过 redis 广播给所有 connector，踢下线指定用户的所有连接
+type KickMsg struct {
+	Uid    string `json:"uid"`
+	Code   int    `json:"code"`   // 踢下线的原因，对应 biz 中的错误码
+	Reason string `json:"reason"` // 错误码对应的说明，发给客户端
+}
+
+// TokenDao token 的吊销列表和踢下线广播
+type TokenDao struct {
+	repo *repo.Manager
+}
+
+func (d *TokenDao) key(tokenId string) string {
+	return Predix + ":" + RevokedTokenRedisKey + ":" + tokenId
+}
+
+// Revoke 吊销 token，记录只保存到 token 过期为止，token 之前已经被吊销时返回 false
+func (d *TokenDao) Revoke(ctx context.Context, tokenId string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		// 已经过期的 token 不需要记录
+		return true, nil
+	}
+	if d.repo.Redis.Client != nil {
+		return d.repo.Redis.Client.SetNX(ctx, d.key(tokenId), 1, ttl).Result()
+	}
+	return d.repo.Redis.ClusterClient.SetNX(ctx, d.key(tokenId), 1, ttl).Result()
+}
+
+// IsRevoked token 是否已经被吊销
+func (d *TokenDao) IsRevoked(ctx context.Context, tokenId string) (bool, error) {
+	var exist int64
+	var err error
+	if d.repo.Redis.Client != nil {
+		exist, err = d.repo.Redis.Client.Exists(ctx, d.key(tokenId)).Result()
+	} else {
+		exist, err = d.repo.Redis.ClusterClient.Exists(ctx, d.key(tokenId)).Result()
+	}
+	return exist > 0, err
+}
+
+// Kick 广播踢下线消息，每个 connector 踢掉自己上面该用户的连接
+func (d *TokenDao) Kick(ctx context.Context, msg KickMsg) error {
+	data, _ := json.Marshal(msg)
+	channel := Predix + ":" + KickRedisChannel
+	if d.repo.Redis.Client != nil {
+		return d.repo.Redis.Client.Publish(ctx, channel, data).Err()
+	}
+	return d.repo.Redis.ClusterClient.Publish(ctx, channel, data).Err()
+}
+
+// SubscribeKick 订阅踢下线广播，ctx 结束时取消订阅
+func (d *TokenDao) SubscribeKick(ctx context.Context, fn func(KickMsg)) {
+	channel := Predix + ":" + KickRedisChannel
+	var sub *redis.PubSub
+	if d.repo.Redis.Client != nil {
+		sub = d.repo.Redis.Client.Subscribe(ctx, channel)
+	} else {
+		sub = d.repo.Redis.ClusterClient.Subscribe(ctx, channel)
+	}
+	go func() {
+		defer sub.Close()
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-ch:
+				if !ok {
+					return
+				}
+				var msg KickMsg
+				if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+					logs.Error("unmarshal kick msg err:%v", err)
+					continue
+				}
+				fn(msg)
+			}
+		}
+	}()
+}
+
+func NewTokenDao(m *repo.Manager) *TokenDao {
+	return &TokenDao{
+		repo: m,
+	}
+}
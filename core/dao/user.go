@@ -7,6 +7,7 @@ import (
 	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Data Access Object (DAO) 数据访问对象
@@ -48,23 +49,53 @@ func (d UserDao) Insert(ctx context.Context, user *entity.User) error {
 	return err
 }
 
-// UpdateUserAddressByUid 通过用户的UID更新用户的地址信息
-func (d UserDao) UpdateUserAddressByUid(todo context.Context, user *entity.User) error {
-	// 获取user集合
+// FindUsersByUids 根据多个 UID 批量查找用户信息，不存在的用户不在结果中
+func (d UserDao) FindUsersByUids(ctx context.Context, uids []string) ([]*entity.User, error) {
 	db := d.repo.Mongo.Db.Collection("user")
-	// 更新一个文档
-	_, err := db.UpdateOne(todo, bson.M{
-		"uid": user.Uid,
-	}, bson.M{
-		"$set": bson.M{
-			"address":  user.Address,
-			"Location": user.Location,
-		},
+	cursor, err := db.Find(ctx, bson.M{
+		"uid": bson.M{"$in": uids},
 	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	users := make([]*entity.User, 0, len(uids))
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UpdateUserByUid 修改用户的指定字段，返回修改后的用户，用户不存在时返回 nil
+func (d UserDao) UpdateUserByUid(ctx context.Context, uid string, set bson.M) (*entity.User, error) {
+	db := d.repo.Mongo.Db.Collection("user")
+	user := new(entity.User)
+	err := db.FindOneAndUpdate(ctx, bson.M{
+		"uid": uid,
+	}, bson.M{
+		"$set": set,
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
 	}
-	return nil
+	return user, err
+}
+
+// IncGoldByUid 原子地增减用户的金币，扣减后金币不能小于 0，
+// 用户不存在或金币不足时返回 nil
+func (d UserDao) IncGoldByUid(ctx context.Context, uid string, delta int64) (*entity.User, error) {
+	db := d.repo.Mongo.Db.Collection("user")
+	filter := bson.M{"uid": uid}
+	if delta < 0 {
+		filter["gold"] = bson.M{"$gte": -delta}
+	}
+	user := new(entity.User)
+	err := db.FindOneAndUpdate(ctx, filter, bson.M{
+		"$inc": bson.M{"gold": delta},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	return user, err
 }
 
 // NewUserDao 创建并返回一个新的 UserDao 实例
@@ -15,5 +15,7 @@ type Account struct {
 	Password     string             `bson:"password"`
 	PhoneAccount string             `bson:"phoneAccount"`
 	WxAccount    string             `bson:"wxAccount"`
+	DeviceId     string             `bson:"deviceId"` // 游客账号绑定的设备
+	Guest        bool               `bson:"guest"`    // 是否为游客，绑定手机号或微信后不再是游客
 	CreateTime   time.Time          `bson:"createTime"`
 }
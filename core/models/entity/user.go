@@ -1,11 +1,16 @@
 package entity
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
 
 type User struct {
 	Id               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Uid              string             `bson:"uid" json:"uid"`                           // 用户唯一ID
 	IsBlockedAccount int                `bson:"isBlockedAccount" json:"isBlockedAccount"` // 是否冻结帐号
+	BlockReason      string             `bson:"blockReason" json:"blockReason"`           // 冻结原因
+	BlockExpireAt    int64              `bson:"blockExpireAt" json:"blockExpireAt"`       // 冻结到期时间（毫秒），0 表示永久冻结
 	Location         string             `bson:"location" json:"location"`                 // 地理位置信息，国家省市街道
 	FrontendId       string             `bson:"frontendId" json:"frontendId"`             // 前端服务器ID
 	RoomID           string             `bson:"roomID" json:"roomID"`                     // 房间ID
@@ -26,6 +31,14 @@ type User struct {
 	Avatar           string             `bson:"avatar" json:"avatar"`               // 头像
 }
 
+// IsBlocked 帐号当前是否处于冻结状态，冻结到期后自动失效
+func (u *User) IsBlocked() bool {
+	if u.IsBlockedAccount == 0 {
+		return false
+	}
+	return u.BlockExpireAt == 0 || time.Now().UnixMilli() < u.BlockExpireAt
+}
+
 type InviteMsg struct {
 	Uid       string `bson:"uid" json:"uid"`             // 邀请人ID
 	Nickname  string `bson:"nickname" json:"nickname"`   // 邀请人名字
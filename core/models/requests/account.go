@@ -5,4 +5,5 @@ const (
 	Account
 	WeiXin
 	MobilePhone
+	Guest // 游客，account 为设备 id
 )
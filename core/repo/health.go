@@ -0,0 +1,23 @@
+package repo
+
+import (
+	"common/metrics"
+	"context"
+	"errors"
+)
+
+// RegisterChecks 在监控端口的就绪检查中加入 mongo 和 redis 的连接检查
+func (m *Manager) RegisterChecks() {
+	metrics.RegisterCheck("mongo", func(ctx context.Context) error {
+		if m.Mongo == nil {
+			return errors.New("mongo not connected")
+		}
+		return m.Mongo.Ping(ctx)
+	})
+	metrics.RegisterCheck("redis", func(ctx context.Context) error {
+		if m.Redis == nil {
+			return errors.New("redis not connected")
+		}
+		return m.Redis.Ping(ctx)
+	})
+}
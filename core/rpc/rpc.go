@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"common/config"
+	"common/discovery"
+	"common/logs"
+	"common/metrics"
+	"common/tracing"
+	"common/utils"
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+	"user/pb"
+)
+
+// user 服务是用户数据的唯一所有者，gate、connector、hall 等服务通过这里的客户端读写用户数据
+
+// UserClient 是一个全局的用户服务客户端实例
+var (
+	UserClient pb.UserServiceClient
+)
+
+// Init 初始化 gRPC 客户端连接
+func Init() {
+	r := discovery.NewResolver(config.Conf.Etcd) // 创建一个新的etcd解析器实例
+	resolver.Register(r)                         // 注册解析器
+	domain := config.Conf.Domain["user"]         // 获取用户服务的域名配置
+
+	// 初始化用户服务客户端
+	conn := initClient(r.Scheme(), domain, &UserClient)
+
+	// 就绪检查：etcd 中有可用的 user 服务，并且 grpc 连接正常
+	metrics.RegisterCheck("etcdResolver", r.Check)
+	metrics.RegisterCheck("userService", func(ctx context.Context) error {
+		if state := conn.GetState(); state == connectivity.TransientFailure || state == connectivity.Shutdown {
+			return fmt.Errorf("user service connection %s", state)
+		}
+		return nil
+	})
+}
+
+// initClient 初始化 gRPC 客户端连接
+// scheme 是解析器方案"etcd"，domain 是服务的名称、负载均衡和 TLS 配置，client 是客户端实例
+func initClient(scheme string, domain config.Domain, client interface{}) *grpc.ClientConn {
+	// 构建服务地址
+	addr := fmt.Sprintf("%s:///%s", scheme, domain.Name)
+
+	// 创建 gRPC 连接选项
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(transportCredentials(domain.Tls)),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor()), // 将 trace 信息传给服务端
+	}
+
+	// 如果启用负载均衡，则按实例权重分配流量，并摘除连续调用失败的实例
+	if domain.LoadBalance {
+		opts = append(opts, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, discovery.Weighted)))
+	}
+
+	// 创建 gRPC 连接
+	conn, err := grpc.DialContext(context.TODO(), addr, opts...)
+	if err != nil {
+		logs.Fatal("rpc connect etcd error: %v", err)
+	}
+
+	// 根据客户端类型初始化具体的客户端实例
+	switch c := client.(type) {
+	case *pb.UserServiceClient:
+		*c = pb.NewUserServiceClient(conn)
+	default:
+		logs.Fatal("unsupported client type")
+	}
+	return conn
+}
+
+// transportCredentials 配置了 CA 时使用 TLS 连接服务端，配置了客户端证书时使用双向认证，否则不加密
+func transportCredentials(conf config.TlsConf) credentials.TransportCredentials {
+	if conf.CaFile == "" {
+		return insecure.NewCredentials()
+	}
+	tlsConf, err := utils.ClientTLSConfig(conf.CaFile, conf.ServerName, conf.CertFile, conf.KeyFile)
+	if err != nil {
+		logs.Fatal("load grpc client tls err:%v", err)
+	}
+	return credentials.NewTLS(tlsConf)
+}
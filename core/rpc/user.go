@@ -0,0 +1,29 @@
+package rpc
+
+import (
+	"core/models/entity"
+	"user/pb"
+)
+
+// ToEntityUser 将 user 服务返回的用户转换为实体，方便沿用原来返回给客户端的数据结构
+func ToEntityUser(user *pb.User) *entity.User {
+	if user == nil {
+		return nil
+	}
+	return &entity.User{
+		Uid:              user.Uid,
+		Nickname:         user.Nickname,
+		Avatar:           user.Avatar,
+		Sex:              int(user.Sex),
+		Gold:             user.Gold,
+		IsBlockedAccount: int(user.IsBlockedAccount),
+		BlockReason:      user.BlockReason,
+		BlockExpireAt:    user.BlockExpireAt,
+		MobilePhone:      user.MobilePhone,
+		Address:          user.Address,
+		Location:         user.Location,
+		CreateTime:       user.CreateTime,
+		LastLoginTime:    user.LastLoginTime,
+		LastLoginIp:      user.LastLoginIp,
+	}
+}
@@ -3,11 +3,17 @@ package connector
 // connector 用于管理客户端和服务器之间通信的组件：处理网络连接、消息传递和路由管理
 
 import (
+	"common/biz"
+	"common/discovery"
 	"common/logs"
+	"common/metrics"
+	"context"
 	"fmt"
 	"framework/game"
+	"framework/msError"
 	"framework/net"
 	"framework/remote"
+	"net/http"
 )
 
 // Connector 结构体，包括是否运行中的状态、WebSocket管理器和处理器
@@ -15,26 +21,53 @@ type Connector struct {
 	isRunning        bool
 	websocketManager *net.Manager
 	handlers         net.LogicHandler
+	middlewares      []net.Middleware
+	routeMws         map[string][]net.Middleware
+	duplicates       []string         // 重复注册的路由
+	sessionStore     net.SessionStore // 会话数据的持久化存储
+	clientIp         func(r *http.Request) string
+	ipFilter         func(ip string) bool
+	maxConnPerIp     func() int
 	remoteClient     remote.Client
+	registry         *discovery.Register // etcd 中的 connector 注册
+	stopReport       chan struct{}       // 停止上报连接数
+	watcher          *discovery.Watcher  // 监听 etcd 中注册的节点
 }
 
 // Default 函数返回一个默认的Connector实例
 func Default() *Connector {
 	return &Connector{
 		handlers: make(net.LogicHandler),
+		routeMws: make(map[string][]net.Middleware),
 	}
 }
 
 // Run 方法启动Connector服务
 func (c *Connector) Run(serverId string) {
 	if !c.isRunning {
+		if err := c.checkRoutes(); err != nil {
+			logs.Fatal("check routes err:%v", err)
+			return
+		}
+		c.serveRoutes(serverId)
 		// 启动WebSocket和NATS
 		c.websocketManager = net.NewManager()
 		c.websocketManager.ConnectorHandlers = c.handlers
+		c.websocketManager.Middlewares = c.middlewares
+		c.websocketManager.RouteMiddlewares = c.routeMws
+		c.websocketManager.SessionStore = c.sessionStore
+		c.websocketManager.ClientIp = c.clientIp
+		c.websocketManager.IpFilter = c.ipFilter
+		c.websocketManager.MaxConnPerIp = c.maxConnPerIp
 		// 启动nats nats server不会存储消息
 		c.remoteClient = remote.NewNatsClient(serverId, c.websocketManager.RemoteReadChan)
 		c.remoteClient.Run()
 		c.websocketManager.RemoteClient = c.remoteClient
+		// 就绪检查：nats 已连接并订阅、websocket 正在监听
+		metrics.RegisterCheck("nats", c.remoteClient.Check)
+		metrics.RegisterCheck("listening", c.websocketManager.Check)
+		c.watchNodes()
+		c.register(serverId)
 		c.Serve(serverId)
 	}
 }
@@ -42,12 +75,39 @@ func (c *Connector) Run(serverId string) {
 // Close 方法关闭Connector服务
 func (c *Connector) Close() {
 	if c.isRunning {
+		c.closeDiscovery()
 		// 关闭WebSocket和NATS
 		c.websocketManager.Close()
 		c.isRunning = false
 	}
 }
 
+// Drain 优雅停止：先从 etcd 注销，再通知客户端重新连接其它 connector，
+// 等待正在处理的请求和推送完成后关闭连接和 nats，ctx 结束时不再等待
+func (c *Connector) Drain(ctx context.Context) {
+	if !c.isRunning {
+		return
+	}
+	c.deregister()
+	c.websocketManager.Drain(ctx, biz.ServerMaintenance)
+	c.closeDiscovery()
+	if c.remoteClient != nil {
+		c.remoteClient.Close()
+	}
+	c.isRunning = false
+	logs.Info("connector drained")
+}
+
+// KickUser 踢下线用户在当前 connector 上的所有连接，err 作为原因发给客户端
+func (c *Connector) KickUser(uid string, err *msError.Error) {
+	if !c.isRunning {
+		return
+	}
+	if count := c.websocketManager.KickUser(uid, err); count > 0 {
+		logs.Info("kick user, uid=%s, connections=%d, code=%d", uid, count, err.Code)
+	}
+}
+
 // Serve 方法配置并运行websocketManager
 func (c *Connector) Serve(serverId string) {
 	logs.Info("run connector server id:%s", serverId)
@@ -60,6 +120,10 @@ func (c *Connector) Serve(serverId string) {
 		return
 	}
 	addr := fmt.Sprintf("%s:%d", connectorConfig.Host, connectorConfig.ClientPort)
+	// servers.json 修改后重新绑定监听地址
+	game.Conf.OnServersChange(func(old, new game.ServersConf) {
+		c.onServersChange(serverId, new)
+	})
 	c.isRunning = true
 	err := c.websocketManager.Run(addr)
 	if err != nil {
@@ -67,7 +131,64 @@ func (c *Connector) Serve(serverId string) {
 	}
 }
 
-// RegisterHandler 方法注册处理器
+// onServersChange servers.json 变化后，监听地址改变时重新监听；
+// 路由每次都从最新的配置中选择节点，已下线节点上绑定的会话会重新选择节点
+func (c *Connector) onServersChange(serverId string, conf game.ServersConf) {
+	logs.Info("servers config changed, connector:%d, servers:%d", len(conf.Connector), len(conf.Servers))
+	var connectorConfig *game.ConnectorConfig
+	for _, v := range conf.Connector {
+		if v.ID == serverId {
+			connectorConfig = v
+		}
+	}
+	if connectorConfig == nil {
+		logs.Warn("connector config removed, keep listening, serverId=%s", serverId)
+		return
+	}
+	addr := fmt.Sprintf("%s:%d", connectorConfig.Host, connectorConfig.ClientPort)
+	if err := c.websocketManager.Rebind(addr); err != nil {
+		logs.Error("rebind connector err:%v, addr=%s", err, addr)
+	}
+}
+
+// RegisterHandler 方法追加注册处理器，可以多次调用，key 为完整路由 如 entryHandler.entry
 func (c *Connector) RegisterHandler(handlers net.LogicHandler) {
-	c.handlers = handlers
+	c.addHandlers(handlers)
+}
+
+// RegisterGroup 注册一组处理器，key 为方法名，完整路由为 group.method
+func (c *Connector) RegisterGroup(group string, handlers net.LogicHandler) {
+	routes := make(net.LogicHandler, len(handlers))
+	for method, h := range handlers {
+		routes[group+"."+method] = h
+	}
+	c.addHandlers(routes)
+}
+
+// Use 注册全局中间件，对所有 connector 本地路由生效，需在 Run 之前调用
+func (c *Connector) Use(middlewares ...net.Middleware) {
+	c.middlewares = append(c.middlewares, middlewares...)
+}
+
+// UseRoute 注册只对指定路由生效的中间件，在全局中间件之后执行
+func (c *Connector) UseRoute(route string, middlewares ...net.Middleware) {
+	c.routeMws[route] = append(c.routeMws[route], middlewares...)
+}
+
+// SetSessionStore 设置会话数据的持久化存储，connector 重启后玩家重新 entry 时恢复会话
+func (c *Connector) SetSessionStore(store net.SessionStore) {
+	c.sessionStore = store
+}
+
+// SetIpLimit 设置按 IP 的连接限制：filter 判断 IP 是否允许连接，maxPerIp 返回每个 IP 最多的连接数，
+// 每次建立连接时都会调用，可以返回最新的配置，需在 Run 之前调用
+func (c *Connector) SetIpLimit(filter func(ip string) bool, maxPerIp func() int) {
+	c.ipFilter = filter
+	c.maxConnPerIp = maxPerIp
+}
+
+// SetClientIp 设置取出客户端 IP 的方法，connector 部署在代理后面时从受信任代理的请求头中读取，
+// 用于 IP 限制和会话中记录的登录 IP，需在 Run 之前调用
+func (c *Connector) SetClientIp(fn func(r *http.Request) string) {
+	c.clientIp = fn
 }
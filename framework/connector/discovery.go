@@ -0,0 +1,131 @@
+package connector
+
+import (
+	"common/config"
+	"common/discovery"
+	"common/logs"
+	"common/metrics"
+	"framework/game"
+	"time"
+)
+
+// register 将 connector 注册到 etcd，未配置 etcd 时不注册
+func (c *Connector) register(serverId string) {
+	if len(config.Conf.Etcd.Addrs) == 0 {
+		return
+	}
+	serverType := "connector"
+	if connectorConfig := game.Conf.GetConnector(serverId); connectorConfig != nil && connectorConfig.ServerType != "" {
+		serverType = connectorConfig.ServerType
+	}
+	register := discovery.NewRegister()
+	if err := register.Register(discovery.NodeConf(config.Conf.Etcd, serverType, serverId)); err != nil {
+		logs.Error("register connector to etcd err:%v, serverId=%s", err, serverId)
+		return
+	}
+	c.registry = register
+	metrics.RegisterCheck("etcd", register.Check)
+	c.reportLoad(register, game.Conf.GetConnector(serverId))
+}
+
+// loadReportInterval connector 向 etcd 上报连接数的间隔
+const loadReportInterval = 5 * time.Second
+
+// reportLoad 在注册信息中发布给客户端连接的地址，并定时上报连接数，gate 按连接数给客户端分配 connector；
+// 没有配置客户端地址时不发布，gate 不会把客户端分配到这个 connector
+func (c *Connector) reportLoad(register *discovery.Register, conf *game.ConnectorConfig) {
+	if conf == nil {
+		return
+	}
+	host := conf.ClientHost
+	if host == "" && conf.Host != "0.0.0.0" {
+		host = conf.Host
+	}
+	if host == "" {
+		logs.Warn("connector client host not configured, not published to gate, serverId=%s", conf.ID)
+		return
+	}
+	publish := func(load int) {
+		err := register.Publish(func(info *discovery.Server) {
+			info.ClientHost = host
+			info.ClientPort = conf.ClientPort
+			info.Load = load
+		})
+		if err != nil {
+			logs.Error("publish connector load err:%v, serverId=%s", err, conf.ID)
+		}
+	}
+	publish(0)
+	stop := make(chan struct{})
+	c.stopReport = stop
+	go func() {
+		ticker := time.NewTicker(loadReportInterval)
+		defer ticker.Stop()
+		last := 0
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if c.websocketManager == nil {
+					continue
+				}
+				if load := c.websocketManager.Count(); load != last {
+					publish(load)
+					last = load
+				}
+			}
+		}
+	}()
+}
+
+// watchNodes 监听 etcd 中注册的节点，按实时的节点列表路由；
+// 未配置 etcd 或者连接 etcd 失败时，继续使用 servers.json 中的 servers
+func (c *Connector) watchNodes() {
+	if len(config.Conf.Etcd.Addrs) == 0 {
+		return
+	}
+	watcher := discovery.NewWatcher(config.Conf.Etcd, discovery.NodePrefix)
+	err := watcher.Watch(func(servers []discovery.Server) {
+		list := make([]*game.ServersConfig, 0, len(servers))
+		for _, v := range servers {
+			serverType := v.NodeType()
+			if serverType == "" {
+				continue
+			}
+			list = append(list, &game.ServersConfig{
+				ID:         v.Addr,
+				ServerType: serverType,
+			})
+		}
+		game.Conf.SetLiveServers(list)
+		logs.Info("live nodes changed, count=%d", len(list))
+	})
+	if err != nil {
+		logs.Error("watch nodes from etcd err:%v, use servers.json instead", err)
+		return
+	}
+	c.watcher = watcher
+	metrics.RegisterCheck("etcdWatcher", watcher.Check)
+}
+
+// deregister 从 etcd 中注销 connector
+func (c *Connector) deregister() {
+	if c.stopReport != nil {
+		close(c.stopReport)
+		c.stopReport = nil
+	}
+	if c.registry != nil {
+		c.registry.Close()
+		c.registry = nil
+	}
+}
+
+// closeDiscovery 注销 connector 并停止监听节点
+func (c *Connector) closeDiscovery() {
+	c.deregister()
+	if c.watcher != nil {
+		c.watcher.Close()
+		c.watcher = nil
+	}
+}
@@ -0,0 +1,57 @@
+package connector
+
+import (
+	"common/metrics"
+	"encoding/json"
+	"fmt"
+	"framework/game"
+	"framework/net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// addHandlers 追加注册路由，重复的路由记录下来在 Run 时报错
+func (c *Connector) addHandlers(handlers net.LogicHandler) {
+	for route, handler := range handlers {
+		if _, ok := c.handlers[route]; ok {
+			c.duplicates = append(c.duplicates, route)
+			continue
+		}
+		c.handlers[route] = handler
+	}
+}
+
+// checkRoutes 启动时检查是否有重复注册的路由
+func (c *Connector) checkRoutes() error {
+	if len(c.duplicates) > 0 {
+		sort.Strings(c.duplicates)
+		return fmt.Errorf("duplicate routes: %s", strings.Join(c.duplicates, ", "))
+	}
+	return nil
+}
+
+// Routes 返回 connector 本地处理的所有路由
+func (c *Connector) Routes() []string {
+	routes := make([]string, 0, len(c.handlers))
+	for route := range c.handlers {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// serveRoutes 在监控端口上挂载 /routes，列出 connector 本地处理的路由
+func (c *Connector) serveRoutes(serverId string) {
+	info := map[string]any{
+		"serverId": serverId,
+		"routes":   c.Routes(),
+	}
+	if connectorConfig := game.Conf.GetConnector(serverId); connectorConfig != nil {
+		info["serverType"] = connectorConfig.ServerType
+	}
+	metrics.Handle("/routes", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	}))
+}
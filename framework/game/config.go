@@ -3,13 +3,14 @@ package game
 import (
 	"common/logs"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 	"io"
-	"log"
 	"os"
 	"path"
+	"sync"
 )
 
 // Conf 全局配置变量
@@ -25,6 +26,10 @@ const (
 type Config struct {
 	GameConfig  map[string]GameConfigValue `json:"gameConfig"`
 	ServersConf ServersConf                `json:"serversConf"`
+
+	serversLock sync.RWMutex
+	liveServers map[string][]*ServersConfig // etcd 中发现的节点，不为 nil 时代替 servers.json 参与路由
+	subscribers []func(old, new ServersConf)
 }
 
 // ServersConf 定义了服务器相关的配置结构
@@ -49,6 +54,7 @@ type ConnectorConfig struct {
 	ID         string `json:"id"`
 	Host       string `json:"host"`
 	ClientPort int    `json:"clientPort"`
+	ClientHost string `json:"clientHost"` // 给客户端连接的地址，host 是监听地址（如 0.0.0.0）时需要配置
 	Frontend   bool   `json:"frontend"`
 	ServerType string `json:"serverType"`
 }
@@ -81,45 +87,96 @@ func InitConfig(configDir string) {
 	}
 }
 
-// readServersConfig 读取并解析servers.json配置文件
+// readServersConfig 读取并解析servers.json配置文件，文件修改后校验通过才会替换当前配置
 func readServersConfig(configFile string) {
-	var serversConf ServersConf
 	v := viper.New()
 	v.SetConfigFile(configFile)
 	v.WatchConfig() // 监控配置文件变化
 	v.OnConfigChange(func(e fsnotify.Event) {
-		log.Println("serversConf配置文件被修改")
-		err := v.Unmarshal(&serversConf)
-		if err != nil {
-			panic(fmt.Errorf("serversConf配置文件被修改以后，报错，err:%v \n", err))
+		logs.Info("serversConf配置文件被修改")
+		// 每次都解析到新的结构体中，避免和旧配置合并
+		var serversConf ServersConf
+		if err := v.Unmarshal(&serversConf); err != nil {
+			logs.Error("serversConf配置文件被修改以后，解析报错，继续使用旧配置，err:%v", err)
+			return
 		}
-		Conf.ServersConf = serversConf
-		typeServerConfig()
+		if err := validateServersConf(serversConf); err != nil {
+			logs.Error("serversConf配置文件被修改以后，校验失败，继续使用旧配置，err:%v", err)
+			return
+		}
+		Conf.setServersConf(serversConf)
 	})
 	err := v.ReadInConfig()
 	if err != nil {
 		panic(fmt.Errorf("读取serversConf配置文件报错，err:%v \n", err))
 	}
+	var serversConf ServersConf
 	if err := v.Unmarshal(&serversConf); err != nil {
 		panic(fmt.Errorf("Unmarshal data to Conf failed ，err:%v \n", err))
 	}
-	Conf.ServersConf = serversConf
-	typeServerConfig()
+	if err := validateServersConf(serversConf); err != nil {
+		panic(fmt.Errorf("serversConf配置文件校验失败，err:%v \n", err))
+	}
+	Conf.setServersConf(serversConf)
 }
 
-// typeServerConfig 根据服务器类型分类配置
-func typeServerConfig() {
-	if len(Conf.ServersConf.Servers) > 0 {
-		if Conf.ServersConf.TypeServer == nil {
-			Conf.ServersConf.TypeServer = make(map[string][]*ServersConfig)
+// validateServersConf 校验服务器配置：id 不能为空且不能重复，必须指定服务器类型，connector 端口必须合法
+func validateServersConf(conf ServersConf) error {
+	ids := make(map[string]bool)
+	for _, v := range conf.Connector {
+		if v == nil || v.ID == "" {
+			return errors.New("connector id is empty")
+		}
+		if ids[v.ID] {
+			return fmt.Errorf("duplicate server id: %s", v.ID)
+		}
+		ids[v.ID] = true
+		if v.ClientPort <= 0 || v.ClientPort > 65535 {
+			return fmt.Errorf("invalid connector clientPort: %s %d", v.ID, v.ClientPort)
+		}
+	}
+	for _, v := range conf.Servers {
+		if v == nil || v.ID == "" {
+			return errors.New("server id is empty")
+		}
+		if ids[v.ID] {
+			return fmt.Errorf("duplicate server id: %s", v.ID)
 		}
-		for _, v := range Conf.ServersConf.Servers {
-			if Conf.ServersConf.TypeServer[v.ServerType] == nil {
-				Conf.ServersConf.TypeServer[v.ServerType] = make([]*ServersConfig, 0, 10)
-			}
-			Conf.ServersConf.TypeServer[v.ServerType] = append(Conf.ServersConf.TypeServer[v.ServerType], v)
+		ids[v.ID] = true
+		if v.ServerType == "" {
+			return fmt.Errorf("server type is empty: %s", v.ID)
 		}
 	}
+	return nil
+}
+
+// typeServerConfig 根据服务器类型分类配置，每次都生成新的 map
+func typeServerConfig(servers []*ServersConfig) map[string][]*ServersConfig {
+	typeServer := make(map[string][]*ServersConfig)
+	for _, v := range servers {
+		typeServer[v.ServerType] = append(typeServer[v.ServerType], v)
+	}
+	return typeServer
+}
+
+// setServersConf 整体替换服务器配置，然后通知订阅者
+func (c *Config) setServersConf(conf ServersConf) {
+	conf.TypeServer = typeServerConfig(conf.Servers)
+	c.serversLock.Lock()
+	old := c.ServersConf
+	c.ServersConf = conf
+	subscribers := c.subscribers
+	c.serversLock.Unlock()
+	for _, fn := range subscribers {
+		fn(old, conf)
+	}
+}
+
+// OnServersChange 订阅 servers.json 的变化，配置替换成功后按订阅顺序回调
+func (c *Config) OnServersChange(fn func(old, new ServersConf)) {
+	c.serversLock.Lock()
+	defer c.serversLock.Unlock()
+	c.subscribers = append(c.subscribers, fn)
 }
 
 // GameConfigValue 定义了游戏配置的值类型
@@ -166,6 +223,8 @@ func readGameConfig(configFile string) {
 
 // GetConnector 根据服务器ID获取对应的Connector配置
 func (c *Config) GetConnector(serverId string) *ConnectorConfig {
+	c.serversLock.RLock()
+	defer c.serversLock.RUnlock()
 	for _, v := range c.ServersConf.Connector {
 		if v.ID == serverId {
 			return v
@@ -174,8 +233,40 @@ func (c *Config) GetConnector(serverId string) *ConnectorConfig {
 	return nil
 }
 
+// SetLiveServers 使用 etcd 中实时发现的节点列表进行路由，之后 servers.json 中的 servers 不再参与路由
+func (c *Config) SetLiveServers(servers []*ServersConfig) {
+	typeServer := typeServerConfig(servers)
+	c.serversLock.Lock()
+	c.liveServers = typeServer
+	c.serversLock.Unlock()
+}
+
+// GetServers 获取指定类型的所有可路由节点，启用服务发现时返回 etcd 中的实时节点
+func (c *Config) GetServers(serverType string) []*ServersConfig {
+	c.serversLock.RLock()
+	defer c.serversLock.RUnlock()
+	if c.liveServers != nil {
+		return c.liveServers[serverType]
+	}
+	return c.ServersConf.TypeServer[serverType]
+}
+
+// GetServerType 根据服务器ID在 servers.json 中查找服务器类型，找不到时返回空
+func (c *Config) GetServerType(serverId string) string {
+	c.serversLock.RLock()
+	defer c.serversLock.RUnlock()
+	for _, v := range c.ServersConf.Servers {
+		if v.ID == serverId {
+			return v.ServerType
+		}
+	}
+	return ""
+}
+
 // GetConnectorByServerType 根据服务器类型获取对应的Connector配置
 func (c *Config) GetConnectorByServerType(serverType string) *ConnectorConfig {
+	c.serversLock.RLock()
+	defer c.serversLock.RUnlock()
 	for _, v := range c.ServersConf.Connector {
 		if v.ServerType == serverType {
 			return v
@@ -200,3 +291,14 @@ func (c *Config) GetFromGameConfig() map[string]any {
 	}
 	return result
 }
+
+// GetBool 读取 gameConfig.json 中的开关配置，value 可以是 bool 或 "true"/"false" 字符串，不存在时为 false
+func (c *Config) GetBool(key string) bool {
+	switch v := c.GameConfig[key]["value"].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
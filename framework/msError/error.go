@@ -30,3 +30,15 @@ func ToError(err error) *Error {
 	fromError, _ := status.FromError(err)
 	return NewError(int(fromError.Code()), errors.New(fromError.Message()))
 }
+
+// AsError 将任意错误转换为 *Error，grpc 返回的错误会还原其错误码，无法识别时使用 defaultErr
+func AsError(err error, defaultErr *Error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		return ToError(err)
+	}
+	return defaultErr
+}
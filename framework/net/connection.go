@@ -1,9 +1,13 @@
 package net
 
+import "context"
+
 type Connection interface {
 	Close()
 	SendMessage(buf []byte) error
+	SendMessageContext(ctx context.Context, buf []byte) error // 发送队列已满时最多等到 ctx 结束
 	GetSession() *Session
+	Pending() int // 等待发送给客户端的消息数
 }
 
 type MsgPack struct {
@@ -0,0 +1,57 @@
+package net
+
+import (
+	"common/logs"
+	"common/utils"
+	"context"
+	"framework/msError"
+	"time"
+)
+
+// Drain 优雅停止：不再接收新的连接，通知所有客户端到其它 connector 重新连接，
+// 等待正在处理的消息、节点的响应和待发送的消息完成后再断开，ctx 结束时不再等待
+func (m *Manager) Drain(ctx context.Context, reason *msError.Error) {
+	m.draining.Store(true)
+	m.Lock()
+	server := m.server
+	m.server = nil
+	m.Unlock()
+	if server != nil {
+		// 只关闭监听，已经建立的 websocket 连接不受影响
+		m.shutdownServer(server)
+	}
+	// 先复制连接列表再发送，客户端的发送队列已满时不会占着锁阻塞断开连接
+	m.RLock()
+	conns := make([]Connection, 0, len(m.clients))
+	for _, c := range m.clients {
+		conns = append(conns, c)
+	}
+	m.RUnlock()
+	for _, c := range conns {
+		if err := m.Kick(ctx, c, reason); err != nil {
+			logs.Error("kick client err:%v, cid=%s", err, c.GetSession().Cid)
+		}
+	}
+	logs.Info("connector draining, kick clients:%d", len(conns))
+	err := utils.WaitUntil(ctx, 50*time.Millisecond, m.drained)
+	if err != nil {
+		logs.Warn("connector drain timeout, inflight:%d, pending:%d", m.inflight.Load(), m.pending.count())
+	}
+	m.Close()
+}
+
+// drained 客户端消息、节点响应、推送都已经处理完，并且所有消息都已经写给客户端
+func (m *Manager) drained() bool {
+	if len(m.ClientReadChan) > 0 || m.inflight.Load() > 0 || m.pending.count() > 0 ||
+		len(m.RemoteReadChan) > 0 || len(m.RemotePushChan) > 0 {
+		return false
+	}
+	m.RLock()
+	defer m.RUnlock()
+	for _, c := range m.clients {
+		if c.Pending() > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package net
+
+import (
+	"common/logs"
+	"common/utils"
+	"context"
+	"encoding/json"
+	"framework/msError"
+	"framework/protocol"
+	"time"
+)
+
+// Kick 给客户端发送 Kick 包，err 说明踢出的原因，连接由客户端或之后的 Close 断开，
+// 客户端的发送队列已满时最多等到 ctx 结束
+func (m *Manager) Kick(ctx context.Context, c Connection, err *msError.Error) error {
+	data, _ := json.Marshal(protocol.KickBody{Code: err.Code, Reason: err.Error()})
+	buf, e := protocol.Encode(protocol.Kick, data)
+	if e != nil {
+		return e
+	}
+	return c.SendMessageContext(ctx, buf)
+}
+
+// KickUser 踢下线用户在当前 connector 上的所有连接，Kick 包写给客户端后再断开连接，返回踢掉的连接数
+func (m *Manager) KickUser(uid string, err *msError.Error) int {
+	var conns []Connection
+	m.RLock()
+	for _, c := range m.clients {
+		if c.GetSession().GetUid() == uid {
+			conns = append(conns, c)
+		}
+	}
+	m.RUnlock()
+	ctx, cancel := context.WithTimeout(context.Background(), writeWait)
+	defer cancel()
+	for _, c := range conns {
+		if e := m.Kick(ctx, c, err); e != nil {
+			logs.Error("kick client err:%v, cid=%s", e, c.GetSession().Cid)
+		}
+		go closeAfterFlush(c)
+	}
+	return len(conns)
+}
+
+// closeAfterFlush 等待待发送的消息写完再断开连接，最多等待 writeWait
+func closeAfterFlush(c Connection) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeWait)
+	defer cancel()
+	_ = utils.WaitUntil(ctx, 50*time.Millisecond, func() bool {
+		return c.Pending() == 0
+	})
+	c.Close()
+}
@@ -0,0 +1,89 @@
+package net
+
+import (
+	"common"
+	"common/biz"
+	"common/logs"
+	"common/tracing"
+	"common/utils"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware 包装 connector 本地路由的处理函数，route 为当前包装的路由
+type Middleware func(route string, next HandlerFunc) HandlerFunc
+
+// chain 按注册顺序包装处理函数，先注册的中间件在最外层
+func chain(route string, handler HandlerFunc, middlewares ...Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](route, handler)
+	}
+	return handler
+}
+
+// Recovery 捕获处理函数中的 panic，给客户端返回失败而不是断开连接
+func Recovery() Middleware {
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *Session, body []byte) (result any, err error) {
+			defer func() {
+				if e := recover(); e != nil {
+					logs.With(logs.Route, route, logs.Uid, session.GetUid(), logs.Cid, session.Cid).
+						Error("handler panic, err=%v\n%s", e, debug.Stack())
+					result, err = common.Fail(biz.Fail), nil
+				}
+			}()
+			return next(session, body)
+		}
+	}
+}
+
+// Logging 记录每个请求的路由、用户和耗时
+func Logging() Middleware {
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *Session, body []byte) (any, error) {
+			start := time.Now()
+			result, err := next(session, body)
+			logs.With(logs.Route, route, logs.Uid, session.GetUid(), logs.Cid, session.Cid, logs.TraceId, tracing.TraceId(session.Context())).
+				Info("handle cost=%v, err=%v", time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// AuthRequired 要求请求必须来自已经 entry 的连接
+func AuthRequired() Middleware {
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *Session, body []byte) (any, error) {
+			if len(session.GetUid()) <= 0 {
+				return common.Fail(biz.InvalidUsers), nil
+			}
+			return next(session, body)
+		}
+	}
+}
+
+// Metrics 统计每个路由的处理耗时，由 observe 决定如何上报
+func Metrics(observe func(route string, cost time.Duration)) Middleware {
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *Session, body []byte) (any, error) {
+			start := time.Now()
+			result, err := next(session, body)
+			observe(route, time.Since(start))
+			return result, err
+		}
+	}
+}
+
+// RateLimit 限制同一连接在 per 时间内对同一路由最多请求 limit 次
+func RateLimit(limit int, per time.Duration) Middleware {
+	limiter := utils.NewLimiter(limit, per)
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *Session, body []byte) (any, error) {
+			if !limiter.Allow(route + ":" + session.Cid) {
+				logs.Warn("rate limited, route=%s, cid=%s", route, session.Cid)
+				return common.Fail(biz.RequestTooFrequent), nil
+			}
+			return next(session, body)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package net
+
+import (
+	"fmt"
+	"framework/game"
+	"sync"
+	"time"
+)
+
+// pendingTimeout 转发给节点的请求超过这个时间没有响应，认为已经丢失（节点宕机、消息丢失等）
+const pendingTimeout = 10 * time.Second
+
+// pendingRequest 已经转发给节点、还没有收到响应的请求
+type pendingRequest struct {
+	dst        string
+	serverType string
+	expireAt   time.Time
+}
+
+// pendingRequests 按请求记录转发给节点的请求，收到响应、超时或目标节点下线后移除
+type pendingRequests struct {
+	sync.Mutex
+	requests  map[string]pendingRequest
+	lastPrune time.Time
+}
+
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{
+		requests: make(map[string]pendingRequest),
+	}
+}
+
+// pendingKey 同一个连接上的请求用消息 id 区分
+func pendingKey(cid string, id uint) string {
+	return fmt.Sprintf("%s#%d", cid, id)
+}
+
+// add 记录转发给 dst 节点的请求
+func (p *pendingRequests) add(key, dst, serverType string) {
+	p.Lock()
+	defer p.Unlock()
+	now := time.Now()
+	// 平时不会调用 count，定期清理一次丢失的请求，避免一直累积
+	if now.Sub(p.lastPrune) > pendingTimeout {
+		p.pruneLocked(now)
+	}
+	p.requests[key] = pendingRequest{
+		dst:        dst,
+		serverType: serverType,
+		expireAt:   now.Add(pendingTimeout),
+	}
+}
+
+// done 收到节点的响应
+func (p *pendingRequests) done(key string) {
+	p.Lock()
+	defer p.Unlock()
+	delete(p.requests, key)
+}
+
+// count 移除已经超时、目标节点已经下线的请求后，返回还在等待响应的请求数
+func (p *pendingRequests) count() int {
+	p.Lock()
+	defer p.Unlock()
+	p.pruneLocked(time.Now())
+	return len(p.requests)
+}
+
+func (p *pendingRequests) pruneLocked(now time.Time) {
+	p.lastPrune = now
+	for key, r := range p.requests {
+		if now.After(r.expireAt) || !isServerLive(r.serverType, r.dst) {
+			delete(p.requests, key)
+		}
+	}
+}
+
+// isServerLive 节点是否还在 etcd 中注册的存活节点里
+func isServerLive(serverType, serverId string) bool {
+	for _, v := range game.Conf.GetServers(serverType) {
+		if v.ID == serverId {
+			return true
+		}
+	}
+	return false
+}
@@ -1,28 +1,65 @@
 package net
 
-import "sync"
+import (
+	"context"
+	"framework/remote"
+	"sync"
+)
 
 // Session 表示一个会话，包含会话ID、用户ID和数据
 type Session struct {
-	sync.RWMutex                // 嵌入读写锁，用于保护并发访问
-	Cid          string         // 会话ID
-	Uid          string         // 用户ID
-	data         map[string]any // 存储会话数据的字典
+	sync.RWMutex                   // 嵌入读写锁，用于保护并发访问
+	Cid          string            // 会话ID
+	Uid          string            // 用户ID
+	Ip           string            // 客户端 IP
+	Serializer   string            // 握手时协商的消息体序列化方式
+	data         map[string]any    // 存储会话数据的字典
+	versions     map[string]uint64 // 每个节点最后应用的 session 增量版本号
+	store        SessionStore      // 会话数据的持久化存储，为空时只保存在内存中
+	storeLock    sync.Mutex        // 保证同一个会话的写入和删除按顺序执行
+	dirty        bool              // 会话数据修改后还没有写入存储
+	flushing     bool              // 已经安排了后台写入
+	ctx          context.Context   // 当前正在处理的请求的上下文
 }
 
 // NewSession 创建一个新的 Session 实例
 func NewSession(cid string) *Session {
 	return &Session{
-		Cid:  cid,
-		data: make(map[string]any), // 初始化 data 字典
+		Cid:      cid,
+		data:     make(map[string]any), // 初始化 data 字典
+		versions: make(map[string]uint64),
 	}
 }
 
+// GetUid 获取会话绑定的用户ID，未 entry 时为空
+func (s *Session) GetUid() string {
+	s.RLock()
+	defer s.RUnlock()
+	return s.Uid
+}
+
+// Context 当前正在处理的请求的上下文，携带链路追踪信息
+func (s *Session) Context() context.Context {
+	s.RLock()
+	defer s.RUnlock()
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+func (s *Session) setContext(ctx context.Context) {
+	s.Lock()
+	defer s.Unlock()
+	s.ctx = ctx
+}
+
 // Put 向会话中添加一个键值对
 func (s *Session) Put(key string, value any) {
-	s.Lock()         // 加写锁，保护 data 字典的并发写操作
-	defer s.Unlock() // 确保在函数退出时释放锁
+	s.Lock() // 加写锁，保护 data 字典的并发写操作
 	s.data[key] = value
+	s.Unlock()
+	s.persist()
 }
 
 // Get 从会话中获取一个键对应的值
@@ -33,13 +70,44 @@ func (s *Session) Get(key string) (any, bool) {
 	return v, ok
 }
 
-// SetData 批量设置会话数据
-func (s *Session) SetData(uid string, data map[string]any) {
+// Data 返回会话数据的快照，发给节点时使用
+func (s *Session) Data() map[string]any {
+	s.RLock()
+	defer s.RUnlock()
+	data := make(map[string]any, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+	return data
+}
+
+// SetData 按顺序应用节点 src 同步过来的 session 增量，
+// 同一个节点的版本号只增不减，重复或乱序到达的旧版本会被丢弃，返回是否应用成功
+func (s *Session) SetData(uid string, src string, version uint64, ops []remote.SessionOp) bool {
+	if !s.applyOps(uid, src, version, ops) {
+		return false
+	}
+	s.persist()
+	return true
+}
+
+func (s *Session) applyOps(uid string, src string, version uint64, ops []remote.SessionOp) bool {
 	s.Lock()
 	defer s.Unlock()
-	if s.Uid == uid {
-		for k, v := range data {
-			s.data[k] = v
+	if s.Uid != uid {
+		return false
+	}
+	if version <= s.versions[src] {
+		return false
+	}
+	s.versions[src] = version
+	for _, op := range ops {
+		switch op.Op {
+		case remote.SessionSet:
+			s.data[op.Key] = op.Value
+		case remote.SessionDel:
+			delete(s.data, op.Key)
 		}
 	}
+	return true
 }
@@ -0,0 +1,121 @@
+package net
+
+import (
+	"common/logs"
+	"context"
+	"time"
+)
+
+// SessionStore 会话数据的持久化存储，按 uid 保存，connector 重启后玩家重新 entry 时恢复
+type SessionStore interface {
+	Load(ctx context.Context, uid string) (map[string]any, error)
+	Save(ctx context.Context, uid string, data map[string]any) error
+	Delete(ctx context.Context, uid string) error
+}
+
+const storeTimeout = 3 * time.Second
+
+// Bind 将会话绑定到用户，并从存储中恢复该用户上一次的会话数据
+func (s *Session) Bind(uid string) {
+	s.Lock()
+	s.Uid = uid
+	s.Unlock()
+	if s.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	data, err := s.store.Load(ctx, uid)
+	if err != nil {
+		logs.Error("load session err:%v, uid=%s", err, uid)
+		return
+	}
+	s.Lock()
+	for k, v := range data {
+		if _, ok := s.data[k]; !ok {
+			s.data[k] = v
+		}
+	}
+	s.Unlock()
+	s.persist()
+}
+
+// Clear 用户登出时清空会话数据并删除存储
+func (s *Session) Clear() {
+	s.Lock()
+	uid := s.Uid
+	s.Uid = ""
+	s.data = make(map[string]any)
+	s.versions = make(map[string]uint64)
+	s.dirty = false
+	s.Unlock()
+	if s.store == nil || uid == "" {
+		return
+	}
+	// 等待正在进行的写入完成后再删除，避免删除后又被写回
+	s.storeLock.Lock()
+	defer s.storeLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := s.store.Delete(ctx, uid); err != nil {
+		logs.Error("delete session err:%v, uid=%s", err, uid)
+	}
+}
+
+// persistDelay 会话数据修改后延迟写入存储的时间，期间的多次修改只写一次
+const persistDelay = 200 * time.Millisecond
+
+// persist 标记会话数据已修改，由后台协程延迟写入存储，不阻塞消息处理
+func (s *Session) persist() {
+	if s.store == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.Uid == "" {
+		return
+	}
+	s.dirty = true
+	if s.flushing {
+		return
+	}
+	s.flushing = true
+	time.AfterFunc(persistDelay, func() {
+		for s.Flush() {
+		}
+	})
+}
+
+// Flush 立即将修改过的会话数据写入存储，同时刷新过期时间，连接断开时调用；
+// 返回 true 表示写入期间会话又被修改，需要再次写入
+func (s *Session) Flush() bool {
+	if s.store == nil {
+		return false
+	}
+	s.storeLock.Lock()
+	defer s.storeLock.Unlock()
+	s.Lock()
+	if !s.dirty || s.Uid == "" {
+		s.flushing = false
+		s.Unlock()
+		return false
+	}
+	s.dirty = false
+	uid := s.Uid
+	data := make(map[string]any, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+	s.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := s.store.Save(ctx, uid, data); err != nil {
+		logs.Error("save session err:%v, uid=%s", err, uid)
+	}
+	s.Lock()
+	defer s.Unlock()
+	if !s.dirty {
+		s.flushing = false
+	}
+	return s.dirty
+}
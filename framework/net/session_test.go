@@ -0,0 +1,89 @@
+package net
+
+import (
+	"framework/remote"
+	"testing"
+)
+
+func TestSessionSetData(t *testing.T) {
+	set := func(key string, value any) []remote.SessionOp {
+		return []remote.SessionOp{{Op: remote.SessionSet, Key: key, Value: value}}
+	}
+	del := func(key string) []remote.SessionOp {
+		return []remote.SessionOp{{Op: remote.SessionDel, Key: key}}
+	}
+	type update struct {
+		uid     string
+		src     string
+		version uint64
+		ops     []remote.SessionOp
+		applied bool
+	}
+	tests := []struct {
+		name    string
+		updates []update
+		want    map[string]any
+	}{
+		{
+			name: "apply in order",
+			updates: []update{
+				{"u1", "game-1", 1, set("room", 1), true},
+				{"u1", "game-1", 2, set("room", 2), true},
+			},
+			want: map[string]any{"room": 2},
+		},
+		{
+			name: "drop duplicate and out of order versions",
+			updates: []update{
+				{"u1", "game-1", 2, set("room", 2), true},
+				{"u1", "game-1", 2, set("room", 3), false},
+				{"u1", "game-1", 1, set("room", 1), false},
+			},
+			want: map[string]any{"room": 2},
+		},
+		{
+			name: "versions are tracked per node",
+			updates: []update{
+				{"u1", "game-1", 5, set("room", 1), true},
+				{"u1", "hall-1", 1, set("hall", 1), true},
+				{"u1", "hall-1", 1, del("hall"), false},
+			},
+			want: map[string]any{"room": 1, "hall": 1},
+		},
+		{
+			name: "delete",
+			updates: []update{
+				{"u1", "game-1", 1, set("room", 1), true},
+				{"u1", "game-1", 2, del("room"), true},
+			},
+			want: map[string]any{},
+		},
+		{
+			name: "drop updates for another user",
+			updates: []update{
+				{"u2", "game-1", 1, set("room", 1), false},
+			},
+			want: map[string]any{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSession("1")
+			s.Uid = "u1"
+			for i, u := range tt.updates {
+				if applied := s.SetData(u.uid, u.src, u.version, u.ops); applied != u.applied {
+					t.Errorf("update %d applied = %v, want %v", i, applied, u.applied)
+				}
+			}
+			got := s.Data()
+			if len(got) != len(tt.want) {
+				t.Fatalf("data = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("data[%s] = %v, want %v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package net
 
 import (
 	"common/logs"
+	"common/metrics"
+	"context"
 	"fmt"
+	"framework/protocol"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"sync/atomic"
@@ -26,6 +29,7 @@ var (
 // WsConnection 结构体管理 WebSocket 连接
 type WsConnection struct {
 	Cid        string
+	Ip         string // 客户端 IP
 	Conn       *websocket.Conn
 	manager    *Manager
 	ReadChan   chan *MsgPack
@@ -45,6 +49,21 @@ func (c WsConnection) SendMessage(buf []byte) error {
 	return nil
 }
 
+// SendMessageContext 发送消息到客户端，发送队列已满（客户端不再读取）时最多等到 ctx 结束
+func (c WsConnection) SendMessageContext(ctx context.Context, buf []byte) error {
+	select {
+	case c.WriteChan <- buf:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pending 等待发送给客户端的消息数
+func (c WsConnection) Pending() int {
+	return len(c.WriteChan)
+}
+
 // Close 关闭连接
 func (c WsConnection) Close() {
 	if c.Conn != nil {
@@ -81,6 +100,8 @@ func (c WsConnection) WriteMessage() {
 			}
 			if err := c.Conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
 				logs.Error("client[%s] write message err :%v", c.Cid, err)
+			} else if len(message) > 0 {
+				metrics.PacketsOut.WithLabelValues(protocol.PackageType(message[0]).String()).Inc()
 			}
 		case <-c.pingTicker.C:
 			if err := c.Conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
@@ -120,7 +141,7 @@ func (c WsConnection) readMessage() {
 				}
 			}
 		} else {
-			logs.Fatal("unsupported message type:", messageType)
+			logs.Fatal("unsupported message type:%v", messageType)
 		}
 	}
 }
@@ -136,12 +157,14 @@ func (c WsConnection) PongHandler(data string) error {
 // NewWsConnection 创建一个新的 WsConnection 实例
 func NewWsConnection(conn *websocket.Conn, manager *Manager) *WsConnection {
 	cid := fmt.Sprintf("%s-%s-%d", uuid.New().String(), manager.ServerId, atomic.AddInt64(&cidBase, 1))
+	session := NewSession(cid)
+	session.store = manager.SessionStore
 	return &WsConnection{
 		Conn:      conn,
 		manager:   manager,
 		Cid:       cid,
 		WriteChan: make(chan []byte, 1024), // 有缓冲 channel
 		ReadChan:  manager.ClientReadChan,
-		Session:   NewSession(cid),
+		Session:   session,
 	}
 }
@@ -1,8 +1,12 @@
 package net
 
 import (
+	"common/ipfilter"
 	"common/logs"
+	"common/metrics"
+	"common/tracing"
 	"common/utils"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,10 +14,13 @@ import (
 	"framework/protocol"
 	"framework/remote"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/trace"
 	"math/rand"
+	stdnet "net"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -47,9 +54,22 @@ type Manager struct {
 	ClientReadChan     chan *MsgPack
 	handlers           map[protocol.PackageType]EventHandler // packet处理器
 	ConnectorHandlers  LogicHandler
+	Middlewares        []Middleware            // 全局中间件
+	RouteMiddlewares   map[string][]Middleware // 路由级中间件
 	RemoteReadChan     chan []byte
 	RemoteClient       remote.Client
 	RemotePushChan     chan *remote.Msg
+	SessionStore       SessionStore // 会话数据的持久化存储，为空时只保存在内存中
+	server             *http.Server // 当前的 websocket 监听
+	stopChan           chan struct{}
+	stopOnce           sync.Once
+	draining           atomic.Bool                  // 停止中，不再接收新的连接
+	inflight           atomic.Int32                 // 正在处理的客户端消息数
+	pending            *pendingRequests             // 已经转发给节点、还没有收到响应的请求
+	ClientIp           func(r *http.Request) string // 取出客户端 IP，为空时使用连接的地址
+	IpFilter           func(ip string) bool         // 是否允许该 IP 建立连接，为空时全部允许
+	MaxConnPerIp       func() int                   // 每个 IP 最多的连接数，为空或小于等于 0 时不限制
+	ipConns            map[string]int               // 每个 IP 当前的连接数
 }
 
 // HandlerFunc 定义处理函数类型
@@ -63,51 +83,175 @@ type EventHandler func(packet *protocol.Packet, c Connection) error
 
 // Run 启动 HTTP 服务器并监听游戏前端的连接
 func (m *Manager) Run(addr string) error {
+	m.wrapHandlers()
 	go m.clientReadChanHandler()
 	go m.remoteReadChanHandler()
 	go m.RemotePushChanHandler()
-	http.HandleFunc("/", m.serveWS) // 将 HTTP 请求交给 m.serveWS 函数处理
 	// 设置不同的消息处理器
 	m.setupEventHandlers()
-	err := http.ListenAndServe(addr, nil) // 启动 HTTP 服务器
+	err := m.Rebind(addr) // 启动 HTTP 服务器
 	if err != nil {
-		logs.Fatal("ListenAndServe: ", err)
+		logs.Fatal("ListenAndServe:%v", err)
 		return err
 	}
+	<-m.stopChan
 	return nil
 }
 
+// Rebind 在新的地址上监听，监听成功后关闭旧的监听，已经建立的连接不受影响
+func (m *Manager) Rebind(addr string) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.server != nil && m.server.Addr == addr {
+		return nil
+	}
+	listener, err := stdnet.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", m.serveWS) // 将 HTTP 请求交给 m.serveWS 函数处理
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logs.Error("websocket serve err:%v, addr=%s", err, addr)
+		}
+	}()
+	old := m.server
+	m.server = server
+	if old != nil {
+		// websocket 连接已经被接管，Shutdown 只会关闭旧的监听
+		go m.shutdownServer(old)
+	}
+	logs.Info("websocket listen on %s", addr)
+	return nil
+}
+
+// Check 检查 websocket 是否正在监听
+func (m *Manager) Check(ctx context.Context) error {
+	if m.draining.Load() {
+		return errors.New("websocket draining")
+	}
+	m.RLock()
+	defer m.RUnlock()
+	if m.server == nil {
+		return errors.New("websocket not listening")
+	}
+	return nil
+}
+
+func (m *Manager) shutdownServer(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logs.Error("shutdown websocket server err:%v, addr=%s", err, server.Addr)
+	}
+}
+
+// wrapHandlers 将中间件包装到每个本地路由的处理函数上
+func (m *Manager) wrapHandlers() {
+	wrapped := make(LogicHandler, len(m.ConnectorHandlers))
+	for route, handler := range m.ConnectorHandlers {
+		middlewares := append(append([]Middleware{}, m.Middlewares...), m.RouteMiddlewares[route]...)
+		wrapped[route] = chain(route, handler, middlewares...)
+	}
+	m.ConnectorHandlers = wrapped
+}
+
 // serveWS 来一个请求，生成一个客户端
 func (m *Manager) serveWS(writer http.ResponseWriter, request *http.Request) {
 	// 如果 websocketUpgrade 未初始化，则使用全局的 websocketUpgrade
 	if m.websocketUpgrade == nil {
 		m.websocketUpgrade = &websocketUpgrade
 	}
+	// 停止中不再接收新的连接，客户端重新从 gate 获取其它 connector
+	if m.draining.Load() {
+		http.Error(writer, "connector draining", http.StatusServiceUnavailable)
+		return
+	}
+	// 按 IP 过滤和限制连接数，先占用名额再升级，避免同一 IP 并发建立连接时超过上限
+	ip := ipfilter.RemoteIp(request.RemoteAddr)
+	if m.ClientIp != nil {
+		ip = m.ClientIp(request)
+	}
+	if m.IpFilter != nil && !m.IpFilter(ip) {
+		logs.Warn("ip forbidden, ip=%s", ip)
+		http.Error(writer, "ip forbidden", http.StatusForbidden)
+		return
+	}
+	if !m.acquireIp(ip) {
+		logs.Warn("too many connections, ip=%s", ip)
+		http.Error(writer, "too many connections", http.StatusTooManyRequests)
+		return
+	}
 	// 升级 HTTP 连接到 WebSocket 连接
 	wsConn, err := m.websocketUpgrade.Upgrade(writer, request, nil)
 	if err != nil {
-		logs.Error("WebSocket upgrade failed: ", err)
+		m.releaseIp(ip)
+		logs.Error("WebSocket upgrade failed:%v", err)
 		return
 	}
 	client := NewWsConnection(wsConn, m) // 每来一个客户端都会生成一个client
+	client.Ip = ip
+	client.Session.Ip = ip
 	m.addClient(client)
 	client.Run()
 }
 
+// acquireIp 占用 ip 的一个连接名额，超过上限时返回 false
+func (m *Manager) acquireIp(ip string) bool {
+	m.Lock()
+	defer m.Unlock()
+	if m.MaxConnPerIp != nil {
+		if max := m.MaxConnPerIp(); max > 0 && m.ipConns[ip] >= max {
+			return false
+		}
+	}
+	m.ipConns[ip]++
+	return true
+}
+
+// releaseIpLocked 释放 ip 的一个连接名额，调用时需要持有锁
+func (m *Manager) releaseIpLocked(ip string) {
+	if m.ipConns[ip] <= 1 {
+		delete(m.ipConns, ip)
+		return
+	}
+	m.ipConns[ip]--
+}
+
+func (m *Manager) releaseIp(ip string) {
+	m.Lock()
+	defer m.Unlock()
+	m.releaseIpLocked(ip)
+}
+
+// Count 当前的客户端连接数
+func (m *Manager) Count() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.clients)
+}
+
 // addClient 将新连接的客户端添加到管理器
 func (m *Manager) addClient(client *WsConnection) {
 	m.Lock()
 	defer m.Unlock()
 	m.clients[client.Cid] = client
+	metrics.Connections.Inc()
 }
 
-// removeClient 从管理器中移除客户端
+// removeClient 从管理器中移除客户端，并释放连接占用的 IP 名额
 func (m *Manager) removeClient(wc WsConnection) {
-	for cid, c := range m.clients {
-		if cid == wc.Cid {
-			c.Close()
-			delete(m.clients, cid)
-		}
+	// 连接断开前把还没有写入的会话数据写入存储，在连接自己的协程中执行，不影响其它连接
+	wc.Session.Flush()
+	m.Lock()
+	defer m.Unlock()
+	if c, ok := m.clients[wc.Cid]; ok {
+		c.Close()
+		delete(m.clients, wc.Cid)
+		metrics.Connections.Dec()
+		m.releaseIpLocked(wc.Ip)
 	}
 }
 
@@ -117,7 +261,9 @@ func (m *Manager) clientReadChanHandler() {
 		select {
 		case body, ok := <-m.ClientReadChan:
 			if ok {
+				m.inflight.Add(1)
 				m.decodeClientPack(body)
+				m.inflight.Add(-1)
 			}
 		}
 	}
@@ -128,20 +274,32 @@ func (m *Manager) decodeClientPack(body *MsgPack) {
 	// 解析协议
 	packet, err := protocol.Decode(body.Body)
 	if err != nil {
-		logs.Error("Decode failed: ", err)
+		logs.Error("Decode failed:%v", err)
 		return
 	}
+	metrics.PacketsIn.WithLabelValues(packet.Type.String()).Inc()
 	if err := m.routeEvent(packet, body.Cid); err != nil {
-		logs.Error("route event failed: ", err)
+		logs.Error("route event failed:%v", err)
 	}
 }
 
 // Close 关闭所有客户端连接
 func (m *Manager) Close() {
+	m.Lock()
+	server := m.server
+	m.server = nil
+	m.Unlock()
+	if server != nil {
+		m.shutdownServer(server)
+	}
 	for cid, v := range m.clients {
 		v.Close()
 		delete(m.clients, cid)
+		metrics.Connections.Dec()
 	}
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+	})
 }
 
 // routeEvent 根据 packet 的类型路由事件
@@ -169,16 +327,20 @@ func (m *Manager) setupEventHandlers() {
 
 // HandshakeHandler 处理握手消息
 func (m *Manager) HandshakeHandler(packet *protocol.Packet, c Connection) error {
+	// 协商消息体的序列化方式，客户端未指定或服务端不支持时使用 json
+	serializer := protocol.NegotiateSerializer(packet.HandshakeBody().Sys.Serializer)
+	c.GetSession().Serializer = serializer
 	response := protocol.HandshakeResponse{
 		Code: 200,
 		Sys: protocol.Sys{
-			Heartbeat: 3,
+			Heartbeat:  3,
+			Serializer: serializer,
 		},
 	}
 	data, _ := json.Marshal(response)
 	buf, err := protocol.Encode(packet.Type, data)
 	if err != nil {
-		logs.Error("Encode failed: ", err)
+		logs.Error("Encode failed:%v", err)
 		return err
 	}
 	return c.SendMessage(buf)
@@ -197,7 +359,7 @@ func (m *Manager) HeartbeatHandler(packet *protocol.Packet, c Connection) error
 	data, _ := json.Marshal(response)
 	buf, err := protocol.Encode(packet.Type, data)
 	if err != nil {
-		logs.Error("Encode failed: ", err)
+		logs.Error("Encode failed:%v", err)
 		return err
 	}
 	return c.SendMessage(buf)
@@ -222,6 +384,11 @@ func (m *Manager) MessageHandler(packet *protocol.Packet, c Connection) error {
 	handlerMethod := fmt.Sprintf("%s.%s", routers[1], routers[2])
 	logs.Info("receiver handler method:%v", handlerMethod)
 
+	// 每个客户端请求都从这里开始一个新的 trace，随本地处理或 nats 消息传递下去
+	ctx, span := tracing.Start(context.Background(), message.Route, trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+	c.GetSession().setContext(ctx)
+
 	// 根据服务器类型获取对应的Connector配置
 	connectorConfig := game.Conf.GetConnectorByServerType(serverType)
 	if connectorConfig != nil {
@@ -233,8 +400,12 @@ func (m *Manager) MessageHandler(packet *protocol.Packet, c Connection) error {
 			if err != nil {
 				return err
 			}
+			// Notify 只处理不回应
+			if message.Type == protocol.Notify {
+				return nil
+			}
 			// 将处理结果封装成响应消息
-			marshal, _ := json.Marshal(data)
+			marshal, _ := protocol.GetSerializer(c.GetSession().Serializer).Marshal(data)
 			message.Type = protocol.Response
 			message.Data = marshal
 			encode, err := protocol.MessageEncode(message)
@@ -250,29 +421,36 @@ func (m *Manager) MessageHandler(packet *protocol.Packet, c Connection) error {
 		}
 	} else {
 		// 如果不是本地connector服务器处理，则通过 NATS 进行远端调用
-		dst, err := m.selectDst(serverType)
+		dst, err := m.selectDst(c.GetSession(), serverType)
 		if err != nil {
-			logs.Error("remote send msg selectDst failed: ", err)
+			logs.Error("remote send msg selectDst failed:%v", err)
 			return err
 		}
-		logs.Info("begin send message by nats, dst:%v, msg:%v", dst, message)
+		logs.With(logs.ServerId, dst, logs.Route, handlerMethod, logs.Uid, c.GetSession().GetUid(), logs.Cid, c.GetSession().Cid, logs.TraceId, tracing.TraceId(ctx)).
+			Info("begin send message by nats")
 		// 构造远端调用消息
 		msg := remote.Msg{
 			Cid:         c.GetSession().Cid,
-			Uid:         c.GetSession().Uid,
+			Uid:         c.GetSession().GetUid(),
 			Src:         m.ServerId,
 			Dst:         dst,
 			Router:      handlerMethod,
 			Body:        message,
-			SessionData: c.GetSession().data,
+			SessionData: c.GetSession().Data(),
+			Serializer:  c.GetSession().Serializer,
+			Trace:       tracing.Inject(ctx),
 		}
 		// 序列化消息并发送
 		data, _ := json.Marshal(msg)
 		err = m.RemoteClient.SendMsg(dst, data)
 		if err != nil {
-			logs.Error("remote send msg failed: ", err)
+			logs.With(logs.ServerId, dst, logs.Route, handlerMethod, logs.Cid, c.GetSession().Cid).
+				Error("remote send msg failed: %v", err)
 			return err
 		}
+		if message.Type == protocol.Request {
+			m.pending.add(pendingKey(msg.Cid, message.ID), dst, serverType)
+		}
 	}
 	return nil
 }
@@ -292,7 +470,7 @@ func (m *Manager) remoteReadChanHandler() {
 				logs.Info("sub nats read chan:%v", string(body))
 				var msg remote.Msg
 				if err := json.Unmarshal(body, &msg); err != nil {
-					logs.Error("Unmarshal msg failed: ", err)
+					logs.Error("Unmarshal msg failed:%v", err)
 					continue
 				}
 				if msg.Type == remote.SessionType {
@@ -301,9 +479,9 @@ func (m *Manager) remoteReadChanHandler() {
 					continue
 				}
 				if msg.Body != nil {
-					if msg.Body.Type == protocol.Response || msg.Body.Type == protocol.Request {
-						// 给客户端回消息，都是response
-						msg.Body.Type = protocol.Response
+					if msg.Body.Type == protocol.Response {
+						// 节点对 Request 的回应，Notify 节点不会回应
+						m.pending.done(pendingKey(msg.Cid, msg.Body.ID))
 						m.Response(&msg)
 					}
 					if msg.Body.Type == protocol.Push {
@@ -315,17 +493,34 @@ func (m *Manager) remoteReadChanHandler() {
 	}
 }
 
-// 选择目的地
-func (m *Manager) selectDst(serverType string) (string, error) {
-	serverConfigs, ok := game.Conf.ServersConf.TypeServer[serverType]
-	if !ok {
+// 选择目的地，同一个会话对同一类型的服务会绑定到同一个节点上（房间等状态保存在节点内存中），
+// 绑定关系保存在会话数据中，绑定的节点下线后重新选择
+func (m *Manager) selectDst(session *Session, serverType string) (string, error) {
+	serverConfigs := game.Conf.GetServers(serverType)
+	if len(serverConfigs) == 0 {
 		return "", errors.New("not found server")
 	}
+	bindKey := routeBindKey(serverType)
+	if bound, ok := session.Get(bindKey); ok {
+		for _, v := range serverConfigs {
+			if v.ID == bound {
+				return v.ID, nil
+			}
+		}
+	}
 	// 随机一个目标服务
 	rand.New(rand.NewSource(time.Now().UnixNano()))
 	index := rand.Intn(len(serverConfigs))
-	return serverConfigs[index].ID, nil
+	dst := serverConfigs[index].ID
+	if session.GetUid() != "" {
+		session.Put(bindKey, dst)
+	}
+	return dst, nil
+}
 
+// routeBindKey 会话中保存路由绑定的 key
+func routeBindKey(serverType string) string {
+	return "route:" + serverType
 }
 
 func (m *Manager) Response(msg *remote.Msg) {
@@ -346,7 +541,7 @@ func (m *Manager) Response(msg *remote.Msg) {
 	}
 	if msg.Body.Type == protocol.Push {
 		for _, v := range m.clients {
-			if utils.Contains(msg.PushUser, v.GetSession().Uid) {
+			if utils.Contains(msg.PushUser, v.GetSession().GetUid()) {
 				logs.Info("Response Push User:%v", msg)
 				v.SendMessage(res)
 			}
@@ -366,7 +561,10 @@ func (m *Manager) RemotePushChanHandler() {
 				logs.Info("nats push channel handler, body:%v", body)
 				if body.Body.Type == protocol.Push {
 					logs.Info("push ,body:%v", body)
+					// 推送延续节点上产生它的请求的 trace
+					_, span := tracing.Start(tracing.Extract(context.Background(), body.Trace), "push "+body.Body.Route)
 					m.Response(body)
+					span.End()
 				}
 			}
 
@@ -379,17 +577,23 @@ func (m *Manager) setSessionData(msg remote.Msg) {
 	defer m.RUnlock()
 	connection, ok := m.clients[msg.Cid]
 	if ok {
-		connection.GetSession().SetData(msg.Uid, msg.SessionData)
+		if !connection.GetSession().SetData(msg.Uid, msg.Src, msg.SessionVersion, msg.SessionOps) {
+			logs.Warn("drop session ops, cid=%s, src=%s, version=%d", msg.Cid, msg.Src, msg.SessionVersion)
+		}
 	}
 }
 
 // NewManager 创建一个新的 Manager 实例
 func NewManager() *Manager {
 	return &Manager{
-		ClientReadChan: make(chan *MsgPack, 1024),
-		clients:        make(map[string]Connection),
-		handlers:       make(map[protocol.PackageType]EventHandler),
-		RemoteReadChan: make(chan []byte, 1024),
-		RemotePushChan: make(chan *remote.Msg, 1024),
+		ClientReadChan:   make(chan *MsgPack, 1024),
+		clients:          make(map[string]Connection),
+		handlers:         make(map[protocol.PackageType]EventHandler),
+		RemoteReadChan:   make(chan []byte, 1024),
+		RemotePushChan:   make(chan *remote.Msg, 1024),
+		RouteMiddlewares: make(map[string][]Middleware),
+		stopChan:         make(chan struct{}),
+		ipConns:          make(map[string]int),
+		pending:          newPendingRequests(),
 	}
 }
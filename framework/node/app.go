@@ -1,36 +1,64 @@
 package node
 
 import (
+	"common"
+	"common/biz"
+	"common/discovery"
 	"common/logs"
+	"common/metrics"
+	"common/tracing"
+	"common/utils"
+	"context"
 	"encoding/json"
+	"framework/protocol"
 	"framework/remote"
+	"go.opentelemetry.io/otel/trace"
+	"sync/atomic"
+	"time"
 )
 
 // App 就是nats的客户端，处理实际游戏逻辑的服务
 type App struct {
 	remoteClient remote.Client
 	readChan     chan []byte
-	writeChan    chan *remote.Msg
+	pipeline     *remote.Pipeline // 响应、推送、session 同步共用的发送管道
 	handlers     LogicHandler
+	middlewares  []Middleware            // 全局中间件
+	routeMws     map[string][]Middleware // 路由级中间件
+	duplicates   []string                // 重复注册的路由
+	registry     *discovery.Register     // etcd 中的节点注册
+	draining     atomic.Bool             // 停止中，不再接收新的房间
+	inflight     atomic.Int32            // 正在处理的消息数
 }
 
 func Default() *App {
 	return &App{
-		readChan:  make(chan []byte),
-		writeChan: make(chan *remote.Msg, 1024),
-		handlers:  make(LogicHandler),
+		readChan: make(chan []byte),
+		handlers: make(LogicHandler),
+		routeMws: make(map[string][]Middleware),
 	}
 }
 
 func (a *App) Run(serverId string) error {
+	if err := a.checkRoutes(); err != nil {
+		logs.Error("check routes err:%v", err)
+		return err
+	}
+	a.serveRoutes(serverId)
+	a.handlers = a.wrapHandlers()
 	a.remoteClient = remote.NewNatsClient(serverId, a.readChan)
 	err := a.remoteClient.Run()
 	if err != nil {
 		logs.Error("remoteClient run err:", err)
 		return err
 	}
+	// 就绪检查：nats 已连接并订阅了当前节点
+	metrics.RegisterCheck("nats", a.remoteClient.Check)
+	a.pipeline = remote.NewPipeline(a.remoteClient)
+	a.pipeline.Run()
 	go a.readChanMsg()
-	go a.writeChanMsg()
+	// 订阅完成后再注册，保证 connector 发现节点时已经可以收到消息
+	a.register(serverId)
 	return nil
 }
 
@@ -40,58 +68,135 @@ func (a *App) readChanMsg() {
 	for {
 		select {
 		case msg := <-a.readChan:
-			var remoteMsg remote.Msg
-			json.Unmarshal(msg, &remoteMsg)
-			session := remote.NewSession(a.remoteClient, &remoteMsg)
-			session.SetData(remoteMsg.SessionData)
-
-			// 根据路由消息， 发送给对应的handler处理
-			router := remoteMsg.Router
-			if handlerFunc := a.handlers[router]; handlerFunc != nil {
-				result := handlerFunc(session, remoteMsg.Body.Data)
-				message := remoteMsg.Body
-				var body []byte
-				if result != nil {
-					body, _ = json.Marshal(result)
-				}
-				message.Data = body
-
-				// 得到结果，发送给connector
-				responseMsg := &remote.Msg{
-					Src:  remoteMsg.Dst,
-					Dst:  remoteMsg.Src,
-					Body: message,
-					Uid:  remoteMsg.Uid,
-					Cid:  remoteMsg.Cid,
-				}
-				a.writeChan <- responseMsg
-			}
+			a.inflight.Add(1)
+			a.handleMsg(msg)
+			a.inflight.Add(-1)
 		}
 	}
 }
 
-func (a *App) writeChanMsg() {
-	for {
-		select {
-		case msg, ok := <-a.writeChan:
-			if ok {
-				marshal, _ := json.Marshal(msg)
-				err := a.remoteClient.SendMsg(msg.Dst, marshal)
-				if err != nil {
-					logs.Error("app remotr send msg err", err)
-				}
-			}
+// handleMsg 处理一条 connector 转发过来的消息
+func (a *App) handleMsg(msg []byte) {
+	var remoteMsg remote.Msg
+	if err := json.Unmarshal(msg, &remoteMsg); err != nil || remoteMsg.Body == nil {
+		logs.Error("unmarshal remote msg err:%v", err)
+		return
+	}
+	// 延续 connector 生成的 trace
+	ctx := tracing.Extract(context.Background(), remoteMsg.Trace)
+	ctx, span := tracing.Start(ctx, remoteMsg.Router, trace.WithSpanKind(trace.SpanKindServer))
+	session := remote.NewSession(a.pipeline, &remoteMsg)
+	session.SetContext(ctx)
+	session.SetData(remoteMsg.SessionData)
+
+	// 根据路由消息， 发送给对应的handler处理
+	router := remoteMsg.Router
+	var result any
+	if handlerFunc := a.handlers[router]; handlerFunc != nil {
+		result = handlerFunc(session, remoteMsg.Body.Data)
+	} else {
+		logs.With(logs.ServerId, remoteMsg.Dst, logs.Route, router, logs.Uid, remoteMsg.Uid, logs.Cid, remoteMsg.Cid).
+			Error("not found handler")
+		result = common.Fail(biz.Fail)
+	}
+	// Notify 只处理不回应，Request 必须且只回应一次
+	if remoteMsg.Body.Type != protocol.Notify {
+		a.response(ctx, &remoteMsg, result)
+	}
+	span.End()
+}
+
+// response 将处理结果作为 Response 发回给请求来源的 connector
+func (a *App) response(ctx context.Context, remoteMsg *remote.Msg, result any) {
+	message := *remoteMsg.Body
+	message.Type = protocol.Response
+	message.Data = nil
+	if result != nil {
+		message.Data, _ = protocol.GetSerializer(remoteMsg.Serializer).Marshal(result)
+	}
+	// 得到结果，发送给connector
+	a.pipeline.Send(&remote.Msg{
+		Src:   remoteMsg.Dst,
+		Dst:   remoteMsg.Src,
+		Body:  &message,
+		Uid:   remoteMsg.Uid,
+		Cid:   remoteMsg.Cid,
+		Trace: tracing.Inject(ctx),
+	})
+}
+
+// Draining 节点是否正在停止
+func (a *App) Draining() bool {
+	return a.draining.Load()
+}
 
+// Drain 优雅停止：注销节点，connector 不再路由新的请求过来；标记为停止中，不再创建新的房间；
+// 取消 nats 订阅后等待已经收到的消息处理完，把响应和推送发完再断开 nats，ctx 结束时不再等待
+func (a *App) Drain(ctx context.Context) {
+	a.draining.Store(true)
+	a.deregister()
+	if a.remoteClient == nil {
+		return
+	}
+	if err := a.remoteClient.Drain(ctx); err != nil {
+		logs.Warn("drain nats subscription err:%v", err)
+	}
+	if err := utils.WaitUntil(ctx, 50*time.Millisecond, func() bool {
+		return a.inflight.Load() == 0
+	}); err != nil {
+		logs.Warn("wait in-flight handlers err:%v, remain:%d", err, a.inflight.Load())
+	}
+	if a.pipeline != nil {
+		if err := a.pipeline.Drain(ctx); err != nil {
+			logs.Warn("drain pipeline err:%v", err)
 		}
 	}
+	a.remoteClient.Close()
+	logs.Info("node drained")
 }
 
 func (a *App) Close() {
+	// 先注销，connector 不再路由新的请求过来
+	a.deregister()
+	if a.pipeline != nil {
+		// 先把队列中的推送发完，再断开 nats
+		a.pipeline.Close()
+	}
 	if a.remoteClient != nil {
 		a.remoteClient.Close()
 	}
 }
 
+// RegisterHandler 追加注册路由，可以多次调用，key 为完整路由 如 unionHandler.createRoom
 func (a *App) RegisterHandler(handler LogicHandler) {
-	a.handlers = handler
+	a.addHandlers(handler)
+}
+
+// RegisterGroup 注册一组处理器，key 为方法名，完整路由为 group.method
+func (a *App) RegisterGroup(group string, handler LogicHandler) {
+	handlers := make(LogicHandler, len(handler))
+	for method, h := range handler {
+		handlers[group+"."+method] = h
+	}
+	a.addHandlers(handlers)
+}
+
+// Use 注册全局中间件，对所有路由生效，需在 Run 之前调用
+func (a *App) Use(middlewares ...Middleware) {
+	a.middlewares = append(a.middlewares, middlewares...)
+}
+
+// UseRoute 注册只对指定路由生效的中间件，在全局中间件之后执行
+func (a *App) UseRoute(route string, middlewares ...Middleware) {
+	a.routeMws[route] = append(a.routeMws[route], middlewares...)
+}
+
+// wrapHandlers 将中间件包装到每个路由的处理函数上
+func (a *App) wrapHandlers() LogicHandler {
+	wrapped := make(LogicHandler, len(a.handlers))
+	for route, handler := range a.handlers {
+		middlewares := append(append([]Middleware{}, a.middlewares...), a.routeMws[route]...)
+		wrapped[route] = chain(route, handler, middlewares...)
+	}
+	return wrapped
 }
@@ -0,0 +1,40 @@
+package node
+
+import (
+	"common/config"
+	"common/discovery"
+	"common/logs"
+	"common/metrics"
+	"framework/game"
+)
+
+// serverType 节点类型，优先从 servers.json 中查找，不在 servers.json 中的节点使用应用名称
+func serverType(serverId string) string {
+	if t := game.Conf.GetServerType(serverId); t != "" {
+		return t
+	}
+	return config.Conf.AppName
+}
+
+// register 将节点注册到 etcd，connector 监听 etcd 发现节点，未配置 etcd 时不注册
+func (a *App) register(serverId string) {
+	if len(config.Conf.Etcd.Addrs) == 0 {
+		return
+	}
+	register := discovery.NewRegister()
+	if err := register.Register(discovery.NodeConf(config.Conf.Etcd, serverType(serverId), serverId)); err != nil {
+		logs.Error("register node to etcd err:%v, serverId=%s", err, serverId)
+		return
+	}
+	a.registry = register
+	metrics.RegisterCheck("etcd", register.Check)
+	logs.Info("register node to etcd success, serverId=%s", serverId)
+}
+
+// deregister 从 etcd 中注销节点，connector 不再把请求路由过来
+func (a *App) deregister() {
+	if a.registry != nil {
+		a.registry.Close()
+		a.registry = nil
+	}
+}
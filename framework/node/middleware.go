@@ -0,0 +1,107 @@
+package node
+
+import (
+	"common"
+	"common/biz"
+	"common/logs"
+	"common/tracing"
+	"common/utils"
+	"framework/remote"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware 包装一个路由的处理函数，route 为当前包装的路由
+type Middleware func(route string, next HandlerFunc) HandlerFunc
+
+// chain 按注册顺序包装处理函数，先注册的中间件在最外层
+func chain(route string, handler HandlerFunc, middlewares ...Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](route, handler)
+	}
+	return handler
+}
+
+// Recovery 捕获处理函数中的 panic，避免单个请求拖垮整个节点
+func Recovery() Middleware {
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *remote.Session, msg []byte) (result any) {
+			defer func() {
+				if err := recover(); err != nil {
+					logs.With(logs.Route, route, logs.Uid, session.GetUid(), logs.Cid, session.GetCid()).
+						Error("handler panic, err=%v\n%s", err, debug.Stack())
+					result = common.Fail(biz.Fail)
+				}
+			}()
+			return next(session, msg)
+		}
+	}
+}
+
+// Logging 记录每个请求的路由、用户和耗时
+func Logging() Middleware {
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *remote.Session, msg []byte) any {
+			start := time.Now()
+			result := next(session, msg)
+			logs.With(logs.Route, route, logs.Uid, session.GetUid(), logs.Cid, session.GetCid(), logs.TraceId, tracing.TraceId(session.Context())).
+				Info("handle cost=%v", time.Since(start))
+			return result
+		}
+	}
+}
+
+// AuthRequired 要求请求必须来自已登录(session中有uid)的用户
+func AuthRequired() Middleware {
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *remote.Session, msg []byte) any {
+			if len(session.GetUid()) <= 0 {
+				return common.Fail(biz.InvalidUsers)
+			}
+			return next(session, msg)
+		}
+	}
+}
+
+// Metrics 统计每个路由的处理耗时，由 observe 决定如何上报
+func Metrics(observe func(route string, cost time.Duration)) Middleware {
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *remote.Session, msg []byte) any {
+			start := time.Now()
+			result := next(session, msg)
+			observe(route, time.Since(start))
+			return result
+		}
+	}
+}
+
+// RateLimit 限制同一用户在 per 时间内对同一路由最多请求 limit 次
+func RateLimit(limit int, per time.Duration) Middleware {
+	limiter := utils.NewLimiter(limit, per)
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *remote.Session, msg []byte) any {
+			key := session.GetUid()
+			if key == "" {
+				key = session.GetCid()
+			}
+			if !limiter.Allow(route + ":" + key) {
+				logs.Warn("rate limited, route=%s, key=%s", route, key)
+				return common.Fail(biz.RequestTooFrequent)
+			}
+			return next(session, msg)
+		}
+	}
+}
+
+// RejectWhenDraining 节点停止中时拒绝请求，用于创建房间等会在节点上留下新状态的路由
+func RejectWhenDraining(draining func() bool) Middleware {
+	return func(route string, next HandlerFunc) HandlerFunc {
+		return func(session *remote.Session, msg []byte) any {
+			if draining() {
+				logs.Warn("node draining, reject route=%s, uid=%s", route, session.GetUid())
+				return common.Fail(biz.ServerMaintenance)
+			}
+			return next(session, msg)
+		}
+	}
+}
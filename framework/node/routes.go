@@ -0,0 +1,60 @@
+package node
+
+import (
+	"common/metrics"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RouteInfo 节点对外提供的路由信息
+type RouteInfo struct {
+	ServerId   string   `json:"serverId"`
+	ServerType string   `json:"serverType"`
+	Routes     []string `json:"routes"`
+}
+
+// addHandlers 追加注册路由，重复的路由记录下来在 Run 时报错
+func (a *App) addHandlers(handlers LogicHandler) {
+	for route, handler := range handlers {
+		if _, ok := a.handlers[route]; ok {
+			a.duplicates = append(a.duplicates, route)
+			continue
+		}
+		a.handlers[route] = handler
+	}
+}
+
+// checkRoutes 启动时检查是否有重复注册的路由
+func (a *App) checkRoutes() error {
+	if len(a.duplicates) > 0 {
+		sort.Strings(a.duplicates)
+		return fmt.Errorf("duplicate routes: %s", strings.Join(a.duplicates, ", "))
+	}
+	return nil
+}
+
+// Routes 返回当前节点注册的所有路由
+func (a *App) Routes() []string {
+	routes := make([]string, 0, len(a.handlers))
+	for route := range a.handlers {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// serveRoutes 在监控端口上挂载 /routes，列出当前节点的路由
+func (a *App) serveRoutes(serverId string) {
+	info := RouteInfo{
+		ServerId:   serverId,
+		ServerType: serverType(serverId),
+		Routes:     a.Routes(),
+	}
+	metrics.Handle("/routes", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	}))
+}
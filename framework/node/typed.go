@@ -0,0 +1,39 @@
+package node
+
+import (
+	"common"
+	"common/biz"
+	"common/logs"
+	"common/utils"
+	"context"
+	"framework/msError"
+	"framework/remote"
+)
+
+// TypedHandlerFunc 带类型的处理函数，请求体由框架按协商的序列化方式解码并校验
+type TypedHandlerFunc[Req any, Resp any] func(ctx context.Context, session *remote.Session, req *Req) (Resp, error)
+
+// Typed 将带类型的处理函数转换为 HandlerFunc，可直接放入 LogicHandler 中注册
+// 解码或 validate 标签校验失败返回 RequestDataError，处理函数返回的错误转换为对应的 msError 错误码，
+// 返回 nil 时响应 common.S(nil)，否则原样返回
+func Typed[Req any, Resp any](fn TypedHandlerFunc[Req, Resp]) HandlerFunc {
+	return func(session *remote.Session, msg []byte) any {
+		req := new(Req)
+		if err := session.Serializer().Unmarshal(msg, req); err != nil {
+			logs.Warn("decode request err:%v, uid=%s", err, session.GetUid())
+			return common.Fail(biz.RequestDataError)
+		}
+		if err := utils.Validate(req); err != nil {
+			logs.Warn("validate request err:%v, uid=%s", err, session.GetUid())
+			return common.Fail(biz.RequestDataError)
+		}
+		resp, err := fn(session.Context(), session, req)
+		if err != nil {
+			return common.Fail(msError.AsError(err, biz.Fail))
+		}
+		if utils.IsNil(resp) {
+			return common.S(nil)
+		}
+		return resp
+	}
+}
@@ -28,6 +28,24 @@ const (
 	Kick         PackageType = 0x05 // Kick 表示一个踢出包
 )
 
+// String 包类型的名称，用于日志和监控指标
+func (p PackageType) String() string {
+	switch p {
+	case Handshake:
+		return "handshake"
+	case HandshakeAck:
+		return "handshakeAck"
+	case Heartbeat:
+		return "heartbeat"
+	case Data:
+		return "data"
+	case Kick:
+		return "kick"
+	default:
+		return "none"
+	}
+}
+
 const (
 	Request  MessageType = 0x00 // ----000-
 	Notify   MessageType = 0x01 // ----001-
@@ -292,6 +310,12 @@ type HandshakeResponse struct {
 	Sys  Sys    `json:"sys"`
 }
 
+// KickBody 服务端踢出客户端时 Kick 包的内容，客户端根据 code 决定是否重新连接
+type KickBody struct {
+	Code   int    `json:"code"`
+	Reason string `json:"reason"`
+}
+
 type Message struct {
 	Type            MessageType // message type 4中消息类型
 	ID              uint        // unique id, zero while notify mode 消息id（request response）
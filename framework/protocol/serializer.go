@@ -0,0 +1,46 @@
+package protocol
+
+import "encoding/json"
+
+// Serializer 消息体(Message.Data)的序列化方式，由客户端在握手时协商
+type Serializer interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+const JsonSerializer = "json"
+
+var serializers = map[string]Serializer{
+	JsonSerializer: jsonSerializer{},
+}
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonSerializer) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// RegisterSerializer 注册自定义的序列化方式，需在服务启动前调用
+func RegisterSerializer(name string, s Serializer) {
+	serializers[name] = s
+}
+
+// NegotiateSerializer 返回服务端支持的序列化方式名称，不支持时退回 json
+func NegotiateSerializer(name string) string {
+	if _, ok := serializers[name]; ok {
+		return name
+	}
+	return JsonSerializer
+}
+
+// GetSerializer 根据名称获取序列化方式，未协商或不支持时使用 json
+func GetSerializer(name string) Serializer {
+	if s, ok := serializers[name]; ok {
+		return s
+	}
+	return serializers[JsonSerializer]
+}
@@ -1,7 +1,11 @@
 package remote
 
+import "context"
+
 type Client interface {
 	Run() error
 	SendMsg(string, []byte) error
 	Close() error
+	Drain(ctx context.Context) error // 取消订阅，等待已经收到的消息交给处理协程
+	Check(ctx context.Context) error // 检查连接和订阅是否正常
 }
@@ -3,15 +3,32 @@ package remote
 import "framework/protocol"
 
 type Msg struct {
-	Cid         string
-	Body        *protocol.Message
-	Src         string
-	Dst         string
-	Router      string
-	Uid         string
-	SessionData map[string]any
-	Type        int // 0 normal 1 session
-	PushUser    []string
+	Cid            string
+	Body           *protocol.Message
+	Src            string
+	Dst            string
+	Router         string
+	Uid            string
+	SessionData    map[string]any // connector 发给节点的 session 快照
+	SessionOps     []SessionOp    // 节点同步给 connector 的 session 增量
+	SessionVersion uint64         // 增量的版本号，同一个节点发出的版本号递增
+	Type           int            // 0 normal 1 session
+	PushUser       []string
+	Serializer     string            // 客户端握手时协商的序列化方式
+	Trace          map[string]string `json:",omitempty"` // 链路追踪信息，由 connector 生成并随消息传递
 }
 
 const SessionType = 1
+
+// session 增量操作类型
+const (
+	SessionSet = "set"
+	SessionDel = "del"
+)
+
+// SessionOp 一次 session 修改，按顺序应用
+type SessionOp struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Value any    `json:"value,omitempty"`
+}
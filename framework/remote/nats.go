@@ -2,18 +2,30 @@ package remote
 
 import (
 	"common/logs"
+	"common/metrics"
+	"common/utils"
+	"context"
+	"errors"
 	"github.com/nats-io/nats.go"
+	"sync"
+	"time"
 )
 
 type NatsClient struct {
-	serverId string
-	conn     *nats.Conn
-	readChan chan []byte
+	serverId     string
+	conn         *nats.Conn
+	readChan     chan []byte
+	subLock      sync.RWMutex
+	subscription *nats.Subscription
 }
 
 func (c *NatsClient) SendMsg(dst string, data []byte) error {
 	if c.conn != nil {
-		return c.conn.Publish(dst, data) // 向目标服务推送消息
+		err := c.conn.Publish(dst, data) // 向目标服务推送消息
+		if err != nil {
+			metrics.NatsPublishErrors.Inc()
+		}
+		return err
 	}
 	return nil
 }
@@ -29,7 +41,7 @@ func (c *NatsClient) Run() error {
 	var err error
 	c.conn, err = nats.Connect("nats://0.0.0.0:4222")
 	if err != nil {
-		logs.Error("Nats connect err:", err)
+		logs.Error("Nats connect err:%v", err)
 		return err
 	}
 	go c.sub() // 订阅
@@ -43,13 +55,47 @@ func (c *NatsClient) Close() error {
 	return nil
 }
 
+// Drain 取消订阅，不再接收新消息，已经到达的消息会继续交给 readChan，
+// 全部交出后返回，连接仍然保留，用于发送剩余的响应和推送
+func (c *NatsClient) Drain(ctx context.Context) error {
+	c.subLock.RLock()
+	sub := c.subscription
+	c.subLock.RUnlock()
+	if sub == nil {
+		return nil
+	}
+	if err := sub.Drain(); err != nil {
+		return err
+	}
+	return utils.WaitUntil(ctx, 50*time.Millisecond, func() bool {
+		return !sub.IsValid()
+	})
+}
+
 func (c *NatsClient) sub() {
-	_, err := c.conn.Subscribe(c.serverId, func(msg *nats.Msg) {
+	sub, err := c.conn.Subscribe(c.serverId, func(msg *nats.Msg) {
 		// 收到其它nats client发送的消息
 		logs.Info("serverId:%v sub msg:%v", c.serverId, string(msg.Data))
 		c.readChan <- msg.Data
 	})
 	if err != nil {
-		logs.Error("nats Subscribe err:", err)
+		logs.Error("nats Subscribe err:%v", err)
+		return
+	}
+	c.subLock.Lock()
+	c.subscription = sub
+	c.subLock.Unlock()
+}
+
+// Check 检查 nats 是否连接并且已经订阅了 serverId
+func (c *NatsClient) Check(ctx context.Context) error {
+	if c.conn == nil || !c.conn.IsConnected() {
+		return errors.New("nats not connected")
+	}
+	c.subLock.RLock()
+	defer c.subLock.RUnlock()
+	if c.subscription == nil || !c.subscription.IsValid() {
+		return errors.New("nats not subscribed")
 	}
+	return nil
 }
@@ -0,0 +1,160 @@
+package remote
+
+import (
+	"common/logs"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Pipeline 节点级的消息发送管道，所有 Session 的推送、session 同步以及响应都经由它发送，
+// 由一个协程按顺序批量发送，Session 只是一次请求范围内的视图，不再自己持有协程
+type Pipeline struct {
+	sync.RWMutex
+	client    Client
+	msgChan   chan *Msg
+	closeChan chan struct{}
+	done      chan struct{}
+	closed    bool
+	batchSize int
+	version   uint64 // session 增量的版本号，只在发送协程中递增
+}
+
+func NewPipeline(client Client) *Pipeline {
+	return &Pipeline{
+		client:    client,
+		msgChan:   make(chan *Msg, 1024),
+		closeChan: make(chan struct{}),
+		done:      make(chan struct{}),
+		batchSize: 64,
+		// 以启动时间为起点，节点重启后版本号仍然比之前发出的大
+		version: uint64(time.Now().UnixNano()),
+	}
+}
+
+// Run 启动发送协程
+func (p *Pipeline) Run() {
+	go p.sendLoop()
+}
+
+// Send 将消息放入发送队列，管道关闭后的消息会被丢弃
+func (p *Pipeline) Send(msg *Msg) {
+	p.RLock()
+	defer p.RUnlock()
+	if p.closed {
+		logs.Warn("pipeline closed, drop msg dst:%v, cid:%v", msg.Dst, msg.Cid)
+		return
+	}
+	p.msgChan <- msg
+}
+
+// Close 不再接收新消息，等待队列中的消息全部发送完成
+func (p *Pipeline) Close() {
+	p.Lock()
+	if p.closed {
+		p.Unlock()
+		return
+	}
+	p.closed = true
+	p.Unlock()
+	close(p.closeChan)
+	<-p.done
+}
+
+// Drain 同 Close，但最多等待到 ctx 结束，超时后队列中剩余的消息由发送协程继续尽力发送
+func (p *Pipeline) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		logs.Warn("pipeline drain timeout, remain msg:%d", len(p.msgChan))
+		return ctx.Err()
+	}
+}
+
+func (p *Pipeline) sendLoop() {
+	defer close(p.done)
+	batch := make([]*Msg, 0, p.batchSize)
+	for {
+		select {
+		case msg := <-p.msgChan:
+			batch = append(batch[:0], msg)
+			// 把队列中已有的消息一起取出来，合并后批量发送
+			for len(batch) < p.batchSize && p.collect(&batch) {
+			}
+			p.flush(batch)
+		case <-p.closeChan:
+			// 发送完剩余的消息再退出
+			for {
+				batch = batch[:0]
+				for len(batch) < p.batchSize && p.collect(&batch) {
+				}
+				if len(batch) == 0 {
+					return
+				}
+				p.flush(batch)
+			}
+		}
+	}
+}
+
+// collect 非阻塞地取出一条消息，队列为空时返回 false
+func (p *Pipeline) collect(batch *[]*Msg) bool {
+	select {
+	case msg := <-p.msgChan:
+		*batch = append(*batch, msg)
+		return true
+	default:
+		return false
+	}
+}
+
+// flush 合并同一连接的 session 增量后按顺序发送，session 增量在这里生成版本号
+func (p *Pipeline) flush(batch []*Msg) {
+	for _, msg := range mergeSessionMsg(batch) {
+		if msg.Type == SessionType {
+			p.version++
+			msg.SessionVersion = p.version
+		}
+		data, _ := json.Marshal(msg)
+		if err := p.client.SendMsg(msg.Dst, data); err != nil {
+			logs.Error("pipeline send msg err:%v, dst=%v", err, msg.Dst)
+		}
+	}
+}
+
+// mergeSessionMsg 同一批次中发往同一连接的 session 增量按顺序合并为一条，
+// 合并后的消息放在该连接最后一条 session 消息的位置，其它消息的顺序保持不变
+func mergeSessionMsg(batch []*Msg) []*Msg {
+	last := make(map[string]int)
+	merged := make(map[string][]SessionOp)
+	for i, msg := range batch {
+		if msg.Type != SessionType {
+			continue
+		}
+		key := msg.Dst + "/" + msg.Cid
+		last[key] = i
+		merged[key] = append(merged[key], msg.SessionOps...)
+	}
+	if len(last) == 0 {
+		return batch
+	}
+	result := make([]*Msg, 0, len(batch))
+	for i, msg := range batch {
+		if msg.Type == SessionType {
+			key := msg.Dst + "/" + msg.Cid
+			if last[key] != i {
+				continue
+			}
+			msg.SessionOps = merged[key]
+		}
+		result = append(result, msg)
+	}
+	return result
+}
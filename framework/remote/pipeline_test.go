@@ -0,0 +1,89 @@
+package remote
+
+import (
+	"reflect"
+	"testing"
+)
+
+func sessionMsg(dst, cid string, keys ...string) *Msg {
+	ops := make([]SessionOp, 0, len(keys))
+	for _, k := range keys {
+		ops = append(ops, SessionOp{Op: SessionSet, Key: k, Value: k})
+	}
+	return &Msg{Type: SessionType, Dst: dst, Cid: cid, SessionOps: ops}
+}
+
+func normalMsg(dst, cid, router string) *Msg {
+	return &Msg{Dst: dst, Cid: cid, Router: router}
+}
+
+// describe 把消息转成便于比较的字符串：普通消息为路由，session 消息为连接和增量的 key
+func describe(batch []*Msg) []string {
+	result := make([]string, 0, len(batch))
+	for _, msg := range batch {
+		if msg.Type != SessionType {
+			result = append(result, msg.Router)
+			continue
+		}
+		s := "session:" + msg.Dst + "/" + msg.Cid + ":"
+		for _, op := range msg.SessionOps {
+			s += op.Key
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+func TestMergeSessionMsg(t *testing.T) {
+	tests := []struct {
+		name  string
+		batch []*Msg
+		want  []string
+	}{
+		{
+			name:  "empty",
+			batch: nil,
+			want:  []string{},
+		},
+		{
+			name:  "no session msg",
+			batch: []*Msg{normalMsg("c1", "1", "a"), normalMsg("c1", "2", "b")},
+			want:  []string{"a", "b"},
+		},
+		{
+			name: "merge into last position in order",
+			batch: []*Msg{
+				sessionMsg("c1", "1", "a"),
+				normalMsg("c1", "1", "push1"),
+				sessionMsg("c1", "1", "b", "c"),
+				normalMsg("c1", "1", "push2"),
+			},
+			want: []string{"push1", "session:c1/1:abc", "push2"},
+		},
+		{
+			name: "different connections are not merged",
+			batch: []*Msg{
+				sessionMsg("c1", "1", "a"),
+				sessionMsg("c1", "2", "b"),
+				sessionMsg("c1", "1", "c"),
+			},
+			want: []string{"session:c1/2:b", "session:c1/1:ac"},
+		},
+		{
+			name: "same cid on different connectors are not merged",
+			batch: []*Msg{
+				sessionMsg("c1", "1", "a"),
+				sessionMsg("c2", "1", "b"),
+			},
+			want: []string{"session:c1/1:a", "session:c2/1:b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := describe(mergeSessionMsg(tt.batch))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeSessionMsg() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
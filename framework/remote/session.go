@@ -2,122 +2,106 @@ package remote
 
 import (
 	"common/logs"
-	"encoding/json"
+	"common/tracing"
+	"context"
 	"framework/protocol"
 	"sync"
 )
 
-// Session 存储当前玩家的相关信息
+// Session 存储当前玩家的相关信息，是一次请求范围内的视图，推送和同步都交给节点的 Pipeline 发送
 type Session struct {
 	sync.RWMutex
-	client          Client // 当前客户端
-	msg             *Msg   // 消息
-	pushChan        chan *userPushMsg
-	data            map[string]any
-	pushSessionChan chan map[string]any
+	ctx      context.Context
+	pipeline *Pipeline // 节点共享的发送管道
+	msg      *Msg      // 消息
+	data     map[string]any
 }
 
-// 推送的消息内容是什么，路由是什么
-type pushMsg struct {
-	data   []byte
-	router string
+func NewSession(pipeline *Pipeline, msg *Msg) *Session {
+	return &Session{
+		ctx:      context.Background(),
+		pipeline: pipeline,
+		msg:      msg,
+		data:     make(map[string]any),
+	}
 }
 
-// 给哪些用户推送什么消息
-type userPushMsg struct {
-	PushMsg pushMsg  `json:"pushMsg"`
-	Users   []string `json:"users"`
+func (s *Session) GetUid() string {
+	return s.msg.Uid
 }
 
-func NewSession(client Client, msg *Msg) *Session {
-	s := &Session{
-		client:          client,
-		msg:             msg,
-		pushChan:        make(chan *userPushMsg, 1024),
-		data:            make(map[string]any),
-		pushSessionChan: make(chan map[string]any, 1024),
-	}
-	// 开一个协程，专门去读channel中的消息
-	go s.pushChanRead()
-	go s.pushSessionChanRead()
-	return s
+func (s *Session) GetCid() string {
+	return s.msg.Cid
 }
 
-func (s *Session) GetUid() string {
-	return s.msg.Uid
+// IsNotify 当前消息是否为 Notify，Notify 消息不会给客户端回应
+func (s *Session) IsNotify() bool {
+	return s.msg.Body != nil && s.msg.Body.Type == protocol.Notify
 }
 
-// Push 将房间ID发给玩家
-func (s *Session) Push(user []string, pushMsgData any, route string) {
-	msg, _ := json.Marshal(pushMsgData)
-	_pushMsg := pushMsg{
-		data:   msg,
-		router: route,
-	}
-	_userPushMsg := &userPushMsg{
-		Users:   user,
-		PushMsg: _pushMsg,
-	}
-	// 将包含房间ID的消息送进pushChan通道中
-	logs.Info("push msg:%v", _userPushMsg)
-	s.pushChan <- _userPushMsg
+// Context 当前请求的上下文
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// SetContext 设置当前请求的上下文，节点在调用处理函数前设置，携带链路追踪信息
+func (s *Session) SetContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// Serializer 客户端协商的消息体序列化方式
+func (s *Session) Serializer() protocol.Serializer {
+	return protocol.GetSerializer(s.msg.Serializer)
 }
 
-// 从通道读取数据并发送消息，处理推送逻辑。
-func (s *Session) pushChanRead() {
-	for {
-		select {
-		case data := <-s.pushChan:
-			pushMessage := protocol.Message{
-				Type:  protocol.Push,
-				ID:    s.msg.Body.ID,
-				Route: data.PushMsg.router,
-				Data:  data.PushMsg.data,
-			}
-			msg := Msg{
-				Dst:      s.msg.Src,
-				Src:      s.msg.Dst,
-				Body:     &pushMessage,
-				Cid:      s.msg.Cid,
-				PushUser: data.Users,
-			}
-			result, _ := json.Marshal(msg)
-			logs.Info("push msg dst:%v", msg.Dst)
-			err := s.client.SendMsg(msg.Dst, result)
-			if err != nil {
-				logs.Info("push msg err:%v, msg=%v", err, msg)
-			}
-		}
+// Push 给指定的用户推送消息
+func (s *Session) Push(users []string, pushMsgData any, route string) {
+	data, _ := s.Serializer().Marshal(pushMsgData)
+	pushMessage := protocol.Message{
+		Type:  protocol.Push,
+		ID:    s.msg.Body.ID,
+		Route: route,
+		Data:  data,
 	}
+	msg := &Msg{
+		Dst:      s.msg.Src,
+		Src:      s.msg.Dst,
+		Body:     &pushMessage,
+		Cid:      s.msg.Cid,
+		PushUser: users,
+		Trace:    tracing.Inject(s.ctx),
+	}
+	logs.Info("push msg dst:%v, users:%v, route:%v", msg.Dst, users, route)
+	s.pipeline.Send(msg)
 }
 
+// Put 写入 session 数据，并把这次修改同步给 connector
 func (s *Session) Put(key string, value any) {
 	s.Lock()
-	defer s.Unlock()
 	s.data[key] = value
-	s.pushSessionChan <- s.data // 将新添加的数据同步到Nats
+	s.Unlock()
+	s.sync(SessionOp{Op: SessionSet, Key: key, Value: value})
 }
 
-func (s *Session) pushSessionChanRead() {
-	for {
-		select {
-		case data := <-s.pushSessionChan:
-			_pushMsg := Msg{
-				Dst:         s.msg.Src,
-				Src:         s.msg.Dst,
-				Cid:         s.msg.Cid,
-				Uid:         s.msg.Uid,
-				SessionData: data,
-				Type:        SessionType,
-			}
-			res, _ := json.Marshal(_pushMsg)
-			err := s.client.SendMsg(_pushMsg.Dst, res)
-			if err != nil {
-				logs.Error("push msg err:%v, msg=%v", err, _pushMsg)
-				return
-			}
-		}
-	}
+// Remove 删除 session 数据，并把这次删除同步给 connector
+func (s *Session) Remove(key string) {
+	s.Lock()
+	delete(s.data, key)
+	s.Unlock()
+	s.sync(SessionOp{Op: SessionDel, Key: key})
+}
+
+// sync 只发送本次修改的增量，版本号由 Pipeline 发送时按顺序生成
+func (s *Session) sync(ops ...SessionOp) {
+	s.pipeline.Send(&Msg{
+		Dst:        s.msg.Src,
+		Src:        s.msg.Dst,
+		Cid:        s.msg.Cid,
+		Uid:        s.msg.Uid,
+		SessionOps: ops,
+		Type:       SessionType,
+		Trace:      tracing.Inject(s.ctx),
+	})
 }
 
 func (s *Session) SetData(data map[string]any) {
@@ -3,6 +3,8 @@ package app
 import (
 	"common/config"
 	"common/logs"
+	"common/metrics"
+	"common/tracing"
 	"context"
 	"core/repo"
 	"framework/node"
@@ -17,16 +19,26 @@ import (
 func Run(ctx context.Context, serverId string) error {
 	// 初始化日志库
 	logs.InitLog(config.Conf.AppName)
+	// 初始化链路追踪
+	if err := tracing.Init(config.Conf.AppName); err != nil {
+		logs.Error("init tracing err:%v", err)
+	}
 
 	// 定义一个退出函数
-	exit := func() {}
+	exit := func(ctx context.Context) {}
 	go func() {
 		// 获取默认的连接器实例
 		n := node.Default()
-		exit = n.Close
+		exit = n.Drain
 		manager := repo.New()
+		manager.RegisterChecks()
 		// 注册路由处理器给n
-		n.RegisterHandler(route.Register(manager))
+		route.Register(n, manager)
+		// 注册中间件：panic恢复、请求日志、监控指标、登录校验
+		n.Use(node.Recovery(), node.Logging(), node.Metrics(metrics.ObserveRequest), node.AuthRequired())
+		// 节点停止中不再创建新的房间
+		n.UseRoute("unionHandler.createRoom", node.RateLimit(1, time.Second), node.RejectWhenDraining(n.Draining))
+		n.UseRoute("unionHandler.joinRoom", node.RateLimit(1, time.Second))
 		// 启动连接器
 		err := n.Run(serverId)
 		if err != nil {
@@ -35,11 +47,12 @@ func Run(ctx context.Context, serverId string) error {
 		}
 	}()
 
-	// 优雅启动与停止
+	// 优雅启动与停止：在超时时间内等待正在处理的请求和推送完成
 	stop := func() {
-		exit()
-		// 给出时间让程序停止
-		time.Sleep(3 * time.Second)
+		drainCtx, cancel := context.WithTimeout(context.Background(), config.Conf.Shutdown.GetTimeout())
+		defer cancel()
+		exit(drainCtx)
+		tracing.Close(context.Background())
 		logs.Info("stop app finish")
 	}
 
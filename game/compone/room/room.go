@@ -2,6 +2,7 @@ package room
 
 import (
 	"common/logs"
+	"common/metrics"
 	"core/models/entity"
 	"framework/msError"
 	"framework/remote"
@@ -9,6 +10,7 @@ import (
 	"game/compone/proto"
 	"game/compone/sz"
 	"game/models/request"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -31,6 +33,11 @@ func (r *Room) GetId() string {
 	return r.Id
 }
 
+// GetGameType 房间的游戏类型
+func (r *Room) GetGameType() int {
+	return r.gameRule.GameType
+}
+
 // UserEntryRoom 用户进入房间
 func (r *Room) UserEntryRoom(session *remote.Session, data *entity.User) *msError.Error {
 	r.RoomCreator = &proto.RoomCreator{
@@ -46,6 +53,7 @@ func (r *Room) UserEntryRoom(session *remote.Session, data *entity.User) *msErro
 	_, ok := r.users[data.Uid]
 	if !ok {
 		r.users[data.Uid] = proto.ToRoomUser(data, chairID)
+		metrics.ActivePlayers.WithLabelValues(strconv.Itoa(r.gameRule.GameType)).Inc()
 	}
 	// 2. 将房间号推送给客户端,更新数据库，将当前房间号存储起来
 	r.UpdateUserInfoRoomPush(session, data.Uid)
@@ -123,7 +131,7 @@ func (r *Room) addKickScheduleEvent(session *remote.Session, uid string) {
 
 	// 添加定时任务，30秒后执行
 	r.kickSchedules[uid] = time.AfterFunc(30*time.Second, func() {
-		logs.Info("kick 定时执行，代表 用户长时间未准备,uid=%v", uid)
+		logs.With(logs.RoomId, r.Id, logs.Uid, uid).Info("kick 定时执行，代表 用户长时间未准备")
 		//取消定时任务
 		timer, ok := r.kickSchedules[uid]
 		if ok {
@@ -152,6 +160,10 @@ func (r *Room) ServerMessagePush(users []string, data any, session *remote.Sessi
 func (r *Room) kickUser(user *proto.RoomUser, session *remote.Session) {
 	//将roomId设为空，并将这消息发给当前用户，意味着踢出该用户
 	r.ServerMessagePush([]string{user.UserInfo.Uid}, proto.UpdateUserInfoPush(""), session)
+	if session.GetUid() == user.UserInfo.Uid {
+		// 清除被踢用户 session 中的房间号
+		session.Remove("roomId")
+	}
 	//通知其他人该用户离开房间
 	users := make([]string, 0)
 	for _, v := range r.users {
@@ -159,6 +171,7 @@ func (r *Room) kickUser(user *proto.RoomUser, session *remote.Session) {
 	}
 	r.ServerMessagePush(users, proto.UserLeaveRoomPushData(user), session)
 	delete(r.users, user.UserInfo.Uid) // 将提出的用户删除
+	metrics.ActivePlayers.WithLabelValues(strconv.Itoa(r.gameRule.GameType)).Dec()
 }
 
 // 解散房间
@@ -1,12 +1,10 @@
 package handler
 
 import (
-	"common"
 	"common/biz"
 	"context"
 	"core/repo"
 	"core/service"
-	"encoding/json"
 	"framework/remote"
 	"game/logic"
 	"game/models/request"
@@ -17,64 +15,50 @@ type UnionHandler struct {
 	userService  *service.UserService
 }
 
-func (h *UnionHandler) CreateRoom(session *remote.Session, msg []byte) any {
+func (h *UnionHandler) CreateRoom(ctx context.Context, session *remote.Session, req *request.CreateRomRequest) (any, error) {
 	// union 联盟持有房间
 	// unionManager 管理联盟
 	// room房间关联game接口，实现多个不同的游戏
-	// 1. 接收参数
+	// 1. 参数由框架解码校验，登录校验由 AuthRequired 中间件完成
 	uid := session.GetUid()
-	if len(uid) <= 0 {
-		return common.Fail(biz.InvalidUsers)
-	}
-	var req request.CreateRomRequest
-	if err := json.Unmarshal(msg, &req); err != nil {
-		return common.Fail(biz.RequestDataError)
-	}
 
 	// 2. 根据session用户id 查询用户信息
-	userData, err := h.userService.FindUserByUid(context.TODO(), uid)
+	userData, err := h.userService.FindUserByUid(ctx, uid)
 	if err != nil {
-		return common.Fail(err)
+		return nil, err
 	}
 	if userData == nil {
-		return common.Fail(biz.InvalidUsers)
+		return nil, biz.InvalidUsers
 	}
 
 	// 3. 根据游戏规则、游戏类型、用户信息(创建房间的用户) 创建房间
 	//TODO 需要判断session中是否有roomId，代表此用户已在房间中，不能再次创建房间中
 	union := h.unionManager.GetUnion(req.UnionID)
-	err = union.CreateRoom(h.userService, session, req, userData)
+	err = union.CreateRoom(h.userService, session, *req, userData)
 	if err != nil {
-		return common.Fail(err)
+		return nil, err
 	}
-	return common.S(nil)
+	return nil, nil
 }
 
 // JoinRoom 用户输入房间号加入房间,流程与 CreateRoom 相似
-func (h *UnionHandler) JoinRoom(session *remote.Session, msg []byte) any {
-	// 1. 接收参数
+func (h *UnionHandler) JoinRoom(ctx context.Context, session *remote.Session, req *request.JoinRoomReq) (any, error) {
+	// 1. 参数由框架解码校验，登录校验由 AuthRequired 中间件完成
 	uid := session.GetUid()
-	if len(uid) <= 0 {
-		return common.Fail(biz.InvalidUsers)
-	}
-	var req request.JoinRoomReq
-	if err := json.Unmarshal(msg, &req); err != nil {
-		return common.Fail(biz.RequestDataError)
-	}
 	// 2. 根据session用户id 查询用户信息
-	userData, err := h.userService.FindUserByUid(context.TODO(), uid)
+	userData, err := h.userService.FindUserByUid(ctx, uid)
 	if err != nil {
-		return common.Fail(err)
+		return nil, err
 	}
 	if userData == nil {
-		return common.Fail(biz.InvalidUsers)
+		return nil, biz.InvalidUsers
 	}
 	// 3. 加入房间
 	bizErr := h.unionManager.JoinRoom(session, req.RoomID, userData)
 	if bizErr != nil {
-		return common.Fail(bizErr)
+		return nil, bizErr
 	}
-	return common.S(nil)
+	return nil, nil
 }
 
 func NewUnionHandler(r *repo.Manager, um *logic.UnionManager) *UnionHandler {
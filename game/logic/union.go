@@ -1,12 +1,14 @@
 package logic
 
 import (
+	"common/metrics"
 	"core/models/entity"
 	"core/service"
 	"framework/msError"
 	"framework/remote"
 	"game/compone/room"
 	"game/models/request"
+	"strconv"
 	"sync"
 )
 
@@ -21,6 +23,9 @@ type Union struct {
 func (u *Union) DismissRoom(roomId string) {
 	u.Lock()
 	defer u.Unlock()
+	if r, ok := u.RoomList[roomId]; ok {
+		metrics.ActiveRooms.WithLabelValues(strconv.Itoa(r.GetGameType())).Dec()
+	}
 	delete(u.RoomList, roomId)
 }
 
@@ -29,6 +34,7 @@ func (u *Union) CreateRoom(service *service.UserService, session *remote.Session
 	roomId := u.unionManager.CreateRoomId()
 	newRoom := room.NewRoom(roomId, req.UnionID, req.GameRule, u)
 	u.RoomList[roomId] = newRoom
+	metrics.ActiveRooms.WithLabelValues(strconv.Itoa(newRoom.GetGameType())).Inc()
 
 	// 创建房间后进入房间
 	return newRoom.UserEntryRoom(session, userData)
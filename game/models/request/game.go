@@ -3,7 +3,7 @@ package request
 import "game/compone/proto"
 
 type RoomMessageReq struct {
-	Type proto.RoomMessageType `json:"type"`
+	Type proto.RoomMessageType `json:"type" validate:"required"`
 	Data RoomMessageData       `json:"data"`
 }
 
@@ -10,5 +10,5 @@ type CreateRomRequest struct {
 
 // JoinRoomReq 加入房间的请求
 type JoinRoomReq struct {
-	RoomID string `json:"roomID"`
+	RoomID string `json:"roomID" validate:"required"`
 }
@@ -8,28 +8,23 @@ import (
 	"game/logic"
 )
 
-// Register 函数注册所有的处理器并返回一个 LoginHandler
-func Register(r *repo.Manager) node.LogicHandler {
-	handlers := make(node.LogicHandler)
+// Register 函数按处理器分组，将所有的处理器注册到节点上
+func Register(n *node.App, r *repo.Manager) {
 	unionManager := logic.NewUnionManager()
 
-	// 将 unionHandler 的 createRoom 方法注册到 handlers 中
+	// 注册 unionHandler 的 createRoom、joinRoom 方法
 	unionHandler := handler.NewUnionHandler(r, unionManager) // 创建一个新的 unionHandler 实例
-	handlers["unionHandler.createRoom"] = unionHandler.CreateRoom
-	logs.Info("register handlers userHandler.updateUserAddress")
+	n.RegisterGroup("unionHandler", node.LogicHandler{
+		"createRoom": node.Typed(unionHandler.CreateRoom),
+		"joinRoom":   node.Typed(unionHandler.JoinRoom),
+	})
+	logs.Info("register handlers unionHandler")
 
-	// 将 unionHandler 的 joinRoom 方法注册到 handlers 中
-	handlers["unionHandler.joinRoom"] = unionHandler.JoinRoom
-	logs.Info("register handlers unionHandler.joinRoom")
-
-	// 将 gameHandler 的 roomMessageNotify 方法注册到 handlers 中
+	// 注册 gameHandler 的 roomMessageNotify、gameMessageNotify 方法
 	gameHandler := handler.NewGameHandler(r, unionManager) // 创建一个新的 gameHandler 实例
-	handlers["gameHandler.roomMessageNotify"] = gameHandler.RoomMessageNotify
-	logs.Info("register handlers userHandler.updateUserAddress")
-
-	// 将 gameHandler 的 gameMessageNotify 方法注册到 handlers 中
-	handlers["gameHandler.gameMessageNotify"] = gameHandler.GameMessageNotify
-	logs.Info("register handlers userHandler.updateUserAddress")
-
-	return handlers
+	n.RegisterGroup("gameHandler", node.LogicHandler{
+		"roomMessageNotify": node.Typed(gameHandler.RoomMessageNotify),
+		"gameMessageNotify": gameHandler.GameMessageNotify,
+	})
+	logs.Info("register handlers gameHandler")
 }
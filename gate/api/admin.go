@@ -0,0 +1,72 @@
+package api
+
+import (
+	"common"
+	"common/biz"
+	"common/logs"
+	"core/rpc"
+	"framework/msError"
+	"github.com/gin-gonic/gin"
+	"user/pb"
+)
+
+// 管理接口：冻结和解冻帐号，冻结后该用户的所有连接会被立即踢下线，并且不能再进入游戏
+
+// BlockReq 冻结帐号的请求参数，expireAt 为冻结到期的毫秒时间戳，为 0 时永久冻结
+type BlockReq struct {
+	Uid      string `json:"uid" binding:"required"`
+	Reason   string `json:"reason"`
+	ExpireAt int64  `json:"expireAt"`
+}
+
+// UnblockReq 解冻帐号的请求参数
+type UnblockReq struct {
+	Uid string `json:"uid" binding:"required"`
+}
+
+// AdminHandler 管理接口处理器
+type AdminHandler struct {
+}
+
+// BlockAccount 冻结帐号
+func (a *AdminHandler) BlockAccount(ctx *gin.Context) {
+	var req BlockReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.F(ctx, biz.RequestDataError)
+		return
+	}
+	_, err := rpc.UserClient.BlockAccount(ctx.Request.Context(), &pb.BlockAccountParams{
+		Uid:      req.Uid,
+		Reason:   req.Reason,
+		ExpireAt: req.ExpireAt,
+	})
+	if err != nil {
+		common.F(ctx, msError.ToError(err))
+		return
+	}
+	logs.Info("admin block account uid=%s, reason=%s, expireAt=%d", req.Uid, req.Reason, req.ExpireAt)
+	common.Success(ctx, nil)
+}
+
+// UnblockAccount 解冻帐号
+func (a *AdminHandler) UnblockAccount(ctx *gin.Context) {
+	var req UnblockReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.F(ctx, biz.RequestDataError)
+		return
+	}
+	_, err := rpc.UserClient.UnblockAccount(ctx.Request.Context(), &pb.BlockAccountParams{
+		Uid: req.Uid,
+	})
+	if err != nil {
+		common.F(ctx, msError.ToError(err))
+		return
+	}
+	logs.Info("admin unblock account uid=%s", req.Uid)
+	common.Success(ctx, nil)
+}
+
+// NewAdminHandler 创建管理接口处理器
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{}
+}
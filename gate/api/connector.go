@@ -0,0 +1,90 @@
+package api
+
+import (
+	"common/config"
+	"common/discovery"
+	"common/logs"
+	"common/metrics"
+	"sync"
+)
+
+// 给客户端分配 connector：connector 在 etcd 中发布客户端地址和连接数，gate 选择连接数最少的 connector，
+// 没有可用的 connector（未配置 etcd、etcd 不可用或者没有发布地址）时使用 services.connector 的静态配置
+
+// connectorBalancer 维护 etcd 中在线的 connector 和分配给它们的客户端数
+type connectorBalancer struct {
+	sync.Mutex
+	servers  []discovery.Server
+	assigned map[string]int // 上次上报连接数之后分配到每个 connector 的客户端数，避免上报间隔内都分配到同一个
+}
+
+var connectors = &connectorBalancer{
+	assigned: make(map[string]int),
+}
+
+// InitConnectors 监听 etcd 中注册的 connector，需要在处理登录请求之前调用
+func InitConnectors() {
+	if len(config.Conf.Etcd.Addrs) == 0 {
+		return
+	}
+	watcher := discovery.NewWatcher(config.Conf.Etcd, discovery.NodePrefix)
+	if err := watcher.Watch(connectors.update); err != nil {
+		logs.Error("watch connectors from etcd err:%v, use services.connector instead", err)
+		return
+	}
+	metrics.RegisterCheck("etcdWatcher", watcher.Check)
+}
+
+// update etcd 中的节点变化时更新 connector 列表，只保留发布了客户端地址的 connector
+func (b *connectorBalancer) update(servers []discovery.Server) {
+	list := make([]discovery.Server, 0, len(servers))
+	for _, v := range servers {
+		if v.NodeType() == "" || v.ClientHost == "" || v.ClientPort == 0 {
+			continue
+		}
+		list = append(list, v)
+	}
+	b.Lock()
+	defer b.Unlock()
+	b.servers = list
+	// 收到新的连接数后，之前分配的客户端已经计算在内
+	b.assigned = make(map[string]int, len(list))
+}
+
+// pick 选择连接数最少的 connector，没有可用的 connector 时返回 false
+func (b *connectorBalancer) pick() (discovery.Server, bool) {
+	b.Lock()
+	defer b.Unlock()
+	var best discovery.Server
+	bestLoad := -1
+	for _, v := range b.servers {
+		load := v.Load + b.assigned[v.Addr]
+		if bestLoad < 0 || load < bestLoad {
+			best = v
+			bestLoad = load
+		}
+	}
+	if bestLoad < 0 {
+		return best, false
+	}
+	b.assigned[best.Addr]++
+	return best, true
+}
+
+// serverInfo 给客户端分配的 connector 地址
+func serverInfo() map[string]any {
+	if s, ok := connectors.pick(); ok {
+		return map[string]any{
+			"host": s.ClientHost,
+			"port": s.ClientPort,
+		}
+	}
+	conf := config.Conf.Services["connector"]
+	if len(config.Conf.Etcd.Addrs) > 0 {
+		logs.Warn("no connector available in etcd, use static %s:%d", conf.ClientHost, conf.ClientPort)
+	}
+	return map[string]any{
+		"host": conf.ClientHost,
+		"port": conf.ClientPort,
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"common"
+	"common/biz"
+	"common/config"
+	"common/jwts"
+	"common/logs"
+	"core/rpc"
+	"framework/msError"
+	"github.com/gin-gonic/gin"
+	"user/pb"
+)
+
+// 实现了 token 的刷新和登出：access token 有效期短，过期后用 refresh token 换取新的一对 token，
+// 登出时吊销 token 并踢下线该用户的所有连接。
+
+// RefreshReq 刷新 token 的请求参数
+type RefreshReq struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// LogoutReq 登出的请求参数，access token 放在 Token 头中，refresh token 可以一起吊销
+type LogoutReq struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// RefreshToken 用 refresh token 换取新的一对 token，旧的 refresh token 随即失效，只能使用一次
+func (u *UserHandler) RefreshToken(ctx *gin.Context) {
+	var req RefreshReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.F(ctx, biz.RequestDataError)
+		return
+	}
+	claims, err := jwts.ParseToken(req.RefreshToken)
+	if err != nil || claims.Type != jwts.RefreshToken {
+		common.F(ctx, biz.TokenInfoError)
+		return
+	}
+	response, err := rpc.UserClient.RevokeToken(ctx.Request.Context(), &pb.RevokeTokenParams{
+		Uid:      claims.Uid,
+		TokenId:  claims.ID,
+		ExpireAt: claims.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		common.F(ctx, msError.ToError(err))
+		return
+	}
+	if !response.Revoked {
+		// refresh token 已经使用过或已经登出
+		logs.Warn("refresh token reused, uid=%s", claims.Uid)
+		common.F(ctx, biz.TokenInfoError)
+		return
+	}
+	result, err := genTokens(claims.Uid)
+	if err != nil {
+		logs.Error("gen token err:%v, uid=%s", err, claims.Uid)
+		common.F(ctx, biz.Fail)
+		return
+	}
+	common.Success(ctx, result)
+}
+
+// Logout 吊销当前的 access token（以及传入的 refresh token），并踢下线该用户的所有连接
+func (u *UserHandler) Logout(ctx *gin.Context) {
+	var req LogoutReq
+	if err := ctx.ShouldBindJSON(&req); err != nil && ctx.Request.ContentLength > 0 {
+		common.F(ctx, biz.RequestDataError)
+		return
+	}
+	claims, ok := accessClaims(ctx)
+	if !ok {
+		return
+	}
+	_, err := rpc.UserClient.RevokeToken(ctx.Request.Context(), &pb.RevokeTokenParams{
+		Uid:      claims.Uid,
+		TokenId:  claims.ID,
+		ExpireAt: claims.ExpiresAt.Unix(),
+		Kick:     true,
+	})
+	if err != nil {
+		common.F(ctx, msError.ToError(err))
+		return
+	}
+	if req.RefreshToken != "" {
+		refresh, err := jwts.ParseToken(req.RefreshToken)
+		if err == nil && refresh.Type == jwts.RefreshToken && refresh.Uid == claims.Uid {
+			_, err = rpc.UserClient.RevokeToken(ctx.Request.Context(), &pb.RevokeTokenParams{
+				Uid:      refresh.Uid,
+				TokenId:  refresh.ID,
+				ExpireAt: refresh.ExpiresAt.Unix(),
+			})
+			if err != nil {
+				logs.Error("revoke refresh token err:%v, uid=%s", err, refresh.Uid)
+			}
+		}
+	}
+	logs.Info("logout uid:%v", claims.Uid)
+	common.Success(ctx, nil)
+}
+
+// accessClaims 解析 Token 头中的 access token，无效时直接返回错误给客户端
+func accessClaims(ctx *gin.Context) (*jwts.CustomClaims, bool) {
+	claims, err := jwts.ParseToken(ctx.GetHeader("Token"))
+	if err != nil || claims.Type != jwts.AccessToken {
+		common.F(ctx, biz.TokenInfoError)
+		return nil, false
+	}
+	return claims, true
+}
+
+// genTokens 生成一对 access token 和 refresh token，有效期读取 jwt 配置
+func genTokens(uid string) (map[string]any, error) {
+	token, err := jwts.GenToken(jwts.NewClaims(uid, jwts.AccessToken, config.Conf.Jwt.GetExp()))
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := jwts.GenToken(jwts.NewClaims(uid, jwts.RefreshToken, config.Conf.Jwt.GetRefreshExp()))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"token":        token,
+		"refreshToken": refreshToken,
+	}, nil
+}
@@ -3,19 +3,14 @@ package api
 import (
 	"common"
 	"common/biz"
-	"common/config"
-	"common/jwts"
 	"common/logs"
-	"context"
+	"core/rpc"
 	"framework/msError"
-	"gate/rpc"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"time"
 	"user/pb"
 )
 
-// 实现了用户注册功能，通过RPC服务注册用户，并生成JWT令牌返回给客户端。
+// 实现了用户注册和登录功能，通过RPC服务注册或校验用户，并生成JWT令牌返回给客户端。
 
 // UserHandler 处理用户相关请求的处理器
 type UserHandler struct {
@@ -37,7 +32,7 @@ func (u *UserHandler) Register(ctx *gin.Context) {
 	}
 
 	// 调用RPC服务进行注册，将注册请求发送到用户服务
-	response, err := rpc.UserClient.Register(context.TODO(), &req)
+	response, err := rpc.UserClient.Register(ctx.Request.Context(), &req)
 	if err != nil {
 		common.F(ctx, msError.ToError(err))
 		return
@@ -49,28 +44,79 @@ func (u *UserHandler) Register(ctx *gin.Context) {
 	}
 	// 记录注册成功的用户ID
 	logs.Info("register uid:%v", uid)
+	u.loginResult(ctx, uid)
+}
 
-	// 使用JWT生成token，设置token的有效期为7天
-	claims := jwts.CustomClaims{
-		Uid: uid, // 根据 uid 生成 token
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24 * 7)),
-		},
+// Login 处理用户登录请求，返回和注册一样的 token 和服务器信息
+func (u *UserHandler) Login(ctx *gin.Context) {
+	var req pb.LoginParams
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		common.F(ctx, biz.RequestDataError)
+		return
 	}
-	token, err := jwts.GenToken(&claims, config.Conf.Jwt.Secret)
+	// 调用RPC服务校验账号
+	response, err := rpc.UserClient.Login(ctx.Request.Context(), &req)
 	if err != nil {
-		logs.Error("Register jwt gen token err:%v", err)
-		common.F(ctx, biz.Fail)
+		common.F(ctx, msError.ToError(err))
+		return
+	}
+	uid := response.Uid
+	if len(uid) == 0 {
+		common.F(ctx, biz.SqlError)
 		return
 	}
+	logs.Info("login uid:%v", uid)
+	u.loginResult(ctx, uid)
+}
 
-	// 准备返回结果，包含token和服务器信息
-	result := map[string]any{
-		"token": token,
-		"serverInfo": map[string]any{
-			"host": config.Conf.Services["connector"].ClientHost,
-			"port": config.Conf.Services["connector"].ClientPort,
-		},
+// SendSmsCode 发送短信验证码，用于手机号注册和登录
+func (u *UserHandler) SendSmsCode(ctx *gin.Context) {
+	var req pb.SmsCodeParams
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		common.F(ctx, biz.RequestDataError)
+		return
+	}
+	_, err = rpc.UserClient.SendSmsCode(ctx.Request.Context(), &req)
+	if err != nil {
+		common.F(ctx, msError.ToError(err))
+		return
+	}
+	common.Success(ctx, nil)
+}
+
+// BindAccount 把当前登录的账号（通常是游客）绑定到手机号或微信，uid 保持不变
+func (u *UserHandler) BindAccount(ctx *gin.Context) {
+	claims, ok := accessClaims(ctx)
+	if !ok {
+		return
+	}
+	var req pb.BindAccountParams
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		common.F(ctx, biz.RequestDataError)
+		return
+	}
+	// 只能绑定当前登录的账号
+	req.Uid = claims.Uid
+	_, err := rpc.UserClient.BindAccount(ctx.Request.Context(), &req)
+	if err != nil {
+		common.F(ctx, msError.ToError(err))
+		return
+	}
+	logs.Info("bind account uid:%v, platform:%v", req.Uid, req.LoginPlatform)
+	common.Success(ctx, nil)
+}
+
+// loginResult 根据 uid 生成 token，和 connector 的地址一起返回给客户端
+func (u *UserHandler) loginResult(ctx *gin.Context, uid string) {
+	result, err := genTokens(uid)
+	if err != nil {
+		logs.Error("gen token err:%v, uid=%s", err, uid)
+		common.F(ctx, biz.Fail)
+		return
 	}
+	// 准备返回结果，包含token和服务器信息
+	result["serverInfo"] = serverInfo()
 	common.Success(ctx, result)
 }
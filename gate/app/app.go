@@ -2,33 +2,56 @@ package app
 
 import (
 	"common/config"
+	"common/ipfilter"
+	"common/jwts"
 	"common/logs"
+	"common/tracing"
 	"context"
+	"errors"
 	"fmt"
 	"gate/router"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 )
 
 // Run 启动程序：启动grpc服务 /启动http服务 /启动日志 /启动数据库
 func Run(ctx context.Context) error {
 	logs.InitLog(config.Conf.AppName) // 做一个日志库
+	// 初始化链路追踪
+	if err := tracing.Init(config.Conf.AppName); err != nil {
+		logs.Error("init tracing err:%v", err)
+	}
+	// 加载签发和验证 token 的密钥，配置修改后自动重新加载
+	if err := jwts.Init(); err != nil {
+		logs.Fatal("init jwt keys err:%v", err)
+	}
+	// 加载 IP 黑白名单，配置修改后自动重新加载
+	if err := ipfilter.Init(); err != nil {
+		logs.Fatal("init ip filter err:%v", err)
+	}
+	// gin 启动  注册一个路由
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", config.Conf.HttpPort),
+		Handler: router.RegisterRouter(),
+	}
 	go func() {
-		// gin 启动  注册一个路由
-		r := router.RegisterRouter()
-
 		// http接口
-		err := r.Run(fmt.Sprintf(":%d", config.Conf.HttpPort))
-		if err != nil {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logs.Fatal("gate gin run err:", err)
 		}
 	}()
 
-	// 优雅启动与停止: 信号
+	// 优雅启动与停止: 信号，在超时时间内等待正在处理的请求完成
 	stop := func() {
-		time.Sleep(3 * time.Second) // 给出时间让程序停止
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Conf.Shutdown.GetTimeout())
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logs.Error("gate http shutdown err:%v", err)
+		}
+		tracing.Close(context.Background())
 		logs.Info("stop app finish")
 	}
 	// 监听信号
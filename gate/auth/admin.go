@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"common"
+	"common/biz"
+	"common/config"
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"sync/atomic"
+)
+
+// Admin 校验管理接口的 Admin-Token 头，未配置 admin.token 时拒绝所有管理请求
+func Admin() gin.HandlerFunc {
+	var adminToken atomic.Value
+	adminToken.Store(config.Conf.Admin.Token)
+	// 修改 application.yml 中的 admin.token 后立即生效
+	config.OnChange(func(conf *config.Config) {
+		adminToken.Store(conf.Admin.Token)
+	})
+	return func(c *gin.Context) {
+		token := adminToken.Load().(string)
+		header := c.GetHeader("Admin-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(header), []byte(token)) != 1 {
+			common.F(c, biz.TokenInfoError)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"common"
+	"common/biz"
+	"common/ipfilter"
+	"common/logs"
+	"common/utils"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// IpFilter 拒绝黑名单中的 IP，配置了白名单时只允许白名单中的 IP
+func IpFilter() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if !ipfilter.Allow(ip) {
+			logs.Warn("ip forbidden, ip=%s, path=%s", ip, c.Request.URL.Path)
+			common.F(c, biz.IpForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// IpRateLimit 限制每个 IP 在 per 时间内最多请求 limit 次，limit 小于等于 0 时不限制
+func IpRateLimit(limit int, per time.Duration) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+	limiter := utils.NewLimiter(limit, per)
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if !limiter.Allow(ip) {
+			logs.Warn("ip rate limited, ip=%s, path=%s", ip, c.Request.URL.Path)
+			common.F(c, biz.RequestTooFrequent)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
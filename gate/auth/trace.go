@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"common/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Trace 为每个 http 请求创建 span，后续的 grpc 调用使用 c.Request.Context() 延续同一个 trace
+func Trace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := tracing.ExtractHeader(c.Request.Context(), c.Request.Header)
+		ctx, span := tracing.Start(ctx, c.Request.Method+" "+c.FullPath(), trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+		c.Header("Trace-Id", tracing.TraceId(ctx))
+		c.Next()
+	}
+}
@@ -2,10 +2,12 @@ package router
 
 import (
 	"common/config"
+	"common/logs"
+	"core/rpc"
 	"gate/api"
 	"gate/auth"
-	"gate/rpc"
 	"github.com/gin-gonic/gin"
+	"time"
 )
 
 // router 用于定义和注册 HTTP 路由，配置日志级别、跨域中间件，并初始化 gRPC 客户端。
@@ -22,19 +24,43 @@ func RegisterRouter() *gin.Engine {
 
 	// 初始化 gRPC 客户端，⭐gate 是作为 gRPC 的客户端，去调用 user 的 gRPC 服务
 	rpc.Init()
+	// 监听 etcd 中的 connector，登录时分配连接数最少的 connector
+	api.InitConnectors()
 
 	// 创建一个默认的 Gin 引擎实例
 	r := gin.Default()
 
-	// 使用跨域中间件解决跨域问题
-	r.Use(auth.Cors())
+	// 只信任配置的代理传来的 X-Forwarded-For，否则客户端可以伪造 IP 绕过限制
+	if err := r.SetTrustedProxies(config.Conf.Security.TrustedProxies); err != nil {
+		logs.Fatal("set trusted proxies err:%v", err)
+	}
+
+	// 使用跨域中间件解决跨域问题，并为每个请求创建链路追踪，拒绝黑名单中的 IP
+	r.Use(auth.Cors(), auth.Trace(), auth.IpFilter())
 
 	// 创建一个新的用户处理器实例
 	userHandler := api.NewUserHandler()
 
 	// 在 Gin 框架中注册一个 POST 请求的路由
 	// 将路径为 /register 的 POST 请求映射到 userHandler.Register 处理函数
-	r.POST("/register", userHandler.Register)
+	// 注册和登录按 IP 限制频率
+	r.POST("/register", auth.IpRateLimit(config.Conf.Security.RegisterLimit, time.Minute), userHandler.Register)
+	// 账号密码登录，返回和注册一样的 token 和服务器信息
+	r.POST("/login", auth.IpRateLimit(config.Conf.Security.LoginLimit, time.Minute), userHandler.Login)
+	// 发送短信验证码，手机号注册和登录时使用，按 IP 限制次数，避免同一个 IP 给大量手机号发送短信
+	r.POST("/smsCode", auth.IpRateLimit(config.Conf.Security.SmsLimit, time.Hour), userHandler.SendSmsCode)
+	// access token 过期后用 refresh token 换取新的 token
+	r.POST("/refreshToken", userHandler.RefreshToken)
+	// 登出：吊销 token 并踢下线该用户的所有连接
+	r.POST("/logout", userHandler.Logout)
+	// 游客账号绑定手机号或微信，需要 Token 头
+	r.POST("/bindAccount", userHandler.BindAccount)
+
+	// 管理接口，需要 Admin-Token 头
+	adminHandler := api.NewAdminHandler()
+	admin := r.Group("/admin", auth.Admin())
+	admin.POST("/blockAccount", adminHandler.BlockAccount)
+	admin.POST("/unblockAccount", adminHandler.UnblockAccount)
 
 	return r
 }
@@ -1,42 +1,40 @@
 package handler
 
 import (
-	"common"
 	"common/biz"
 	"common/logs"
+	"context"
 	"core/repo"
-	"core/service"
-	"encoding/json"
+	"core/rpc"
+	"framework/msError"
 	"framework/remote"
 	"hall/models/request"
 	"hall/models/response"
+	"user/pb"
 )
 
 type UserHandler struct {
-	userService *service.UserService
 }
 
 // UpdateUserAddress 更新玩家地址
-func (h *UserHandler) UpdateUserAddress(session *remote.Session, msg []byte) any {
-	logs.Info("UpdateUserAddress msg:%v", string(msg))
-	var req request.UpdateUserAddressReq // 先给定一个结构体格式
-	err := json.Unmarshal(msg, &req)     // Unmarshal() 按照req结构体来反序列化，最后将msg赋值给req
-	if err != nil {
-		return common.Fail(biz.RequestDataError)
-	}
-	err = h.userService.UpdateUserAddressByUid(session.GetUid(), req)
+func (h *UserHandler) UpdateUserAddress(ctx context.Context, session *remote.Session, req *request.UpdateUserAddressReq) (*response.UpdateUserAddressRes, error) {
+	logs.Info("UpdateUserAddress req:%v", req)
+	_, err := rpc.UserClient.UpdateProfile(ctx, &pb.UpdateProfileParams{
+		Uid:      session.GetUid(),
+		Fields:   []string{"address", "location"},
+		Address:  req.Address,
+		Location: req.Location,
+	})
 	if err != nil {
 		logs.Error("UserHandler.UpdateUserAddress err:%v", err)
-		return nil
+		return nil, msError.AsError(err, biz.SqlError)
 	}
-	res := response.UpdateUserAddressRes{}
+	res := &response.UpdateUserAddressRes{}
 	res.Code = biz.OK
-	res.UpdateUserData = req
-	return res
+	res.UpdateUserData = *req
+	return res, nil
 }
 
 func NewUserHandler(r *repo.Manager) *UserHandler {
-	return &UserHandler{
-		userService: service.NewUserService(r),
-	}
+	return &UserHandler{}
 }
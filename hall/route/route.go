@@ -7,16 +7,13 @@ import (
 	"hall/handler"
 )
 
-// Register 函数注册所有的处理器并返回一个 LoginHandler
-func Register(r *repo.Manager) node.LogicHandler {
-	handlers := make(node.LogicHandler)
+// Register 函数按处理器分组，将所有的处理器注册到节点上
+func Register(n *node.App, r *repo.Manager) {
 	userHandler := handler.NewUserHandler(r) // 创建一个新的 NewUserHandler 实例
 
-	// 将 userHandler 的 updateUserAddress 方法注册到 handlers 中
-	handlers["userHandler.updateUserAddress"] = userHandler.UpdateUserAddress
-	logs.Info("register handlers userHandler.updateUserAddress")
-	return handlers
+	// 注册 userHandler 的 updateUserAddress 方法
+	n.RegisterGroup("userHandler", node.LogicHandler{
+		"updateUserAddress": node.Typed(userHandler.UpdateUserAddress),
+	})
+	logs.Info("register handlers userHandler")
 }
-
-// userHandler.updateUserAddress
-// userHandler.updateUserAddress
@@ -3,10 +3,18 @@ package app
 import (
 	"common/config"
 	"common/discovery"
+	"common/interceptor"
 	"common/logs"
+	"common/metrics"
+	"common/tracing"
+	"common/utils"
 	"context"
+	"core/dao"
 	"core/repo"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"net"
 	"os"
 	"os/signal"
@@ -20,10 +28,42 @@ import (
 // ctx 的作用主要是用来接收取消信号
 // 通过 ctx.Done() 通道可以知道什么时候该停止执行当前的操作
 func Run(ctx context.Context) error {
-	logs.InitLog(config.Conf.AppName)   // 做一个日志库
+	logs.InitLog(config.Conf.AppName) // 做一个日志库
+	// 初始化链路追踪
+	if err := tracing.Init(config.Conf.AppName); err != nil {
+		logs.Error("init tracing err:%v", err)
+	}
 	register := discovery.NewRegister() // etcd注册中心 将grpc服务注册到etcd中， 客户端访问时候通过etcd获取grpc的地址
-	server := grpc.NewServer()          // 启动grpc服务端
-	manager := repo.New()               // 初始化数据库 mongo redis
+	// 启动grpc服务端，拦截器：panic恢复、链路追踪、调用统计、请求日志、请求超时
+	opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(
+		interceptor.Recovery(),
+		tracing.UnaryServerInterceptor(),
+		metrics.UnaryServerInterceptor(),
+		interceptor.Logging(),
+		interceptor.Deadline(config.Conf.Grpc.GetTimeout()),
+	)}
+	// 配置了证书时 gate 等客户端需要使用 TLS 连接
+	if tlsConf := config.Conf.Grpc.Tls; tlsConf.CertFile != "" {
+		conf, err := utils.ServerTLSConfig(tlsConf.CertFile, tlsConf.KeyFile, tlsConf.CaFile)
+		if err != nil {
+			logs.Fatal("load grpc tls err:%v", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(conf)))
+	}
+	server := grpc.NewServer(opts...)
+	// 标准的 grpc 健康检查服务，依赖不可用时返回 NOT_SERVING 并从 etcd 中移除
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(server, hs)
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	manager := repo.New()    // 初始化数据库 mongo redis
+	manager.RegisterChecks() // 就绪检查：mongo、redis、etcd 注册状态
+	// 账号表的唯一索引，并发注册、绑定时保证账号、手机号、微信账号不重复，
+	// 创建失败时不影响启动，只在就绪检查中报告，整理数据后重启即可
+	indexErr := ensureAccountIndexes(ctx, dao.NewAccountDao(manager))
+	metrics.RegisterCheck("accountIndexes", func(ctx context.Context) error {
+		return indexErr
+	})
+	metrics.RegisterCheck("etcd", register.Check)
 	go func() {
 		lis, err := net.Listen("tcp", config.Conf.Grpc.Addr) // 阻塞操作，需要放到一个协程当中
 		if err != nil {
@@ -38,6 +78,7 @@ func Run(ctx context.Context) error {
 
 		// 将创建新的账号服务 注册到 gRPC 服务器
 		pb.RegisterUserServiceServer(server, service.NewAccountService(manager))
+		go watchHealth(healthCtx, hs, register)
 		err = server.Serve(lis) // 启动 gRPC 服务器并监听。(阻塞)
 		if err != nil {
 			logs.Fatal("failed to serve:", err)
@@ -46,10 +87,23 @@ func Run(ctx context.Context) error {
 
 	// 优雅启动与停止: 信号
 	stop := func() {
-		server.Stop()
+		// 先从 etcd 注销，gate 不再把请求发过来，再等待正在处理的请求完成，超时后强制停止
+		stopHealth()
+		hs.Shutdown()
 		register.Close()
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(config.Conf.Shutdown.GetTimeout()):
+			logs.Warn("grpc graceful stop timeout, force stop")
+			server.Stop()
+		}
 		manager.Close()
-		time.Sleep(3 * time.Second) // 给出时间让程序停止
+		tracing.Close(context.Background())
 		logs.Info("stop app finish")
 	}
 	// 监听信号
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"common/discovery"
+	"common/logs"
+	"common/metrics"
+	"context"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"time"
+	"user/pb"
+)
+
+// healthInterval 检查依赖并更新健康状态的间隔
+const healthInterval = 5 * time.Second
+
+// healthChecks 决定 user 服务能否处理请求的依赖检查
+var healthChecks = []string{"mongo", "redis"}
+
+// watchHealth 定时检查 mongo、redis，更新 grpc 健康服务的状态，并同步到 etcd：
+// 不可用时从 etcd 中移除，gate 等客户端不再把请求发过来，恢复后重新注册
+func watchHealth(ctx context.Context, hs *health.Server, register *discovery.Register) {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	last := healthpb.HealthCheckResponse_SERVING
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			status := healthpb.HealthCheckResponse_SERVING
+			checkCtx, cancel := context.WithTimeout(ctx, healthInterval)
+			err := metrics.RunChecks(checkCtx, healthChecks...)
+			cancel()
+			if err != nil {
+				status = healthpb.HealthCheckResponse_NOT_SERVING
+			}
+			if status != last {
+				logs.Warn("user service health changed, status=%s, err=%v", status, err)
+				last = status
+			}
+			hs.SetServingStatus("", status)
+			hs.SetServingStatus(pb.UserService_ServiceDesc.ServiceName, status)
+			if err := register.SetServing(status == healthpb.HealthCheckResponse_SERVING); err != nil {
+				logs.Error("sync health to etcd err:%v", err)
+			}
+		}
+	}
+}
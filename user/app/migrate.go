@@ -0,0 +1,31 @@
+package app
+
+import (
+	"common/logs"
+	"context"
+	"core/dao"
+)
+
+// ensureAccountIndexes 创建账号表的唯一索引。从没有唯一索引的版本升级时，已有的数据中可能有重复的
+// 微信账号等，创建失败后先整理重复的数据（只保留最早创建的账号，其它账号的值移到 <字段>Dup 中）再重新创建
+func ensureAccountIndexes(ctx context.Context, accountDao *dao.AccountDao) error {
+	err := accountDao.EnsureIndexes(ctx)
+	if err == nil || !dao.IsDuplicateKey(err) {
+		if err != nil {
+			logs.Error("ensure account indexes err:%v", err)
+		}
+		return err
+	}
+	logs.Warn("account has duplicate data, reconcile before creating indexes, err:%v", err)
+	moved, err := accountDao.ReconcileDuplicates(ctx)
+	if err != nil {
+		logs.Error("reconcile duplicate accounts err:%v, reconciled:%d", err, moved)
+		return err
+	}
+	logs.Warn("duplicate accounts reconciled:%d, check the *Dup fields to merge them manually", moved)
+	if err := accountDao.EnsureIndexes(ctx); err != nil {
+		logs.Error("ensure account indexes err:%v", err)
+		return err
+	}
+	return nil
+}
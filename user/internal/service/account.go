@@ -2,7 +2,9 @@ package service
 
 import (
 	"common/biz"
+	"common/config"
 	"common/logs"
+	"common/utils"
 	"context"
 	"core/dao"
 	"core/models/entity"
@@ -10,63 +12,268 @@ import (
 	"core/repo"
 	"framework/msError"
 	"time"
+	"user/internal/sms"
+	"user/internal/wx"
 	"user/pb"
 )
 
 // 实现了用户账户服务的业务逻辑，包括用户注册功能。它使用了 gRPC 作为通信协议，
 // 定义了 AccountService 结构体，并通过 NewAccountService 函数初始化服务。
-// Register 方法实现了具体的注册逻辑，包括微信注册的处理
+// Register 方法实现了具体的注册逻辑，包括微信、账号密码和手机号注册的处理，Login 方法实现各平台的登录
 
 // AccountService 账号服务结构体
 type AccountService struct {
 	accountDao                        *dao.AccountDao
 	redisDao                          *dao.RedisDao
+	smsDao                            *dao.SmsDao
+	smsProvider                       sms.Provider
+	tokenDao                          *dao.TokenDao
+	userDao                           *dao.UserDao
+	wxClient                          *wx.Client
 	pb.UnimplementedUserServiceServer // 作为 gRPC 服务接口的默认实现
 }
 
 // NewAccountService 创建新的账号服务实例
 func NewAccountService(manger *repo.Manager) *AccountService {
+	wxClient := wx.NewClient(config.Conf.Wx)
+	if !wxClient.Enabled() {
+		logs.Warn("wx appId or secret not configured, trust wx account sent by client")
+	}
 	return &AccountService{
 		accountDao: dao.NewAccountDao(manger),
 		redisDao:   dao.NewRedisDao(manger),
+		smsDao: dao.NewSmsDao(manger,
+			time.Duration(config.Conf.Sms.CodeTtl)*time.Second,
+			time.Duration(config.Conf.Sms.Interval)*time.Second),
+		smsProvider: sms.NewProvider(config.Conf.Sms.Provider),
+		tokenDao:    dao.NewTokenDao(manger),
+		userDao:     dao.NewUserDao(manger),
+		wxClient:    wxClient,
 	}
 }
 
 // Register 用户注册方法
 func (a *AccountService) Register(ctx context.Context, req *pb.RegisterParams) (*pb.RegisterResponse, error) {
-	// 处理微信注册逻辑
-	if req.LoginPlatform == requests.WeiXin {
-		ac, err := a.wxRegister(req)
-		if err != nil {
-			return &pb.RegisterResponse{}, msError.GrpcError(err)
-		}
-		return &pb.RegisterResponse{
-			Uid: ac.Uid,
-		}, nil
+	logs.Info("register service called, platform=%d", req.LoginPlatform)
+	var ac *entity.Account
+	var err *msError.Error
+	switch req.LoginPlatform {
+	case requests.WeiXin:
+		// 处理微信注册逻辑
+		ac, err = a.wxRegister(ctx, req)
+	case requests.Account:
+		// 处理账号密码注册逻辑
+		ac, err = a.accountRegister(ctx, req)
+	case requests.MobilePhone:
+		// 处理手机号注册逻辑
+		ac, err = a.phoneRegister(ctx, req)
+	case requests.Guest:
+		// 游客不需要注册，第一次登录时创建账号
+		ac, err = a.guestLogin(ctx, req.Account)
+	default:
+		err = biz.RequestDataError
+	}
+	if err != nil {
+		return &pb.RegisterResponse{}, msError.GrpcError(err)
 	}
-
-	logs.Info("register service called ...")
 	return &pb.RegisterResponse{
-		Uid: "10000",
+		Uid: ac.Uid,
 	}, nil
 }
 
-// 微信账号注册逻辑
-func (a *AccountService) wxRegister(req *pb.RegisterParams) (*entity.Account, *msError.Error) {
+// Login 用户登录方法，校验成功后返回 uid
+func (a *AccountService) Login(ctx context.Context, req *pb.LoginParams) (*pb.LoginResponse, error) {
+	logs.Info("login service called, platform=%d", req.LoginPlatform)
+	var ac *entity.Account
+	var err *msError.Error
+	switch req.LoginPlatform {
+	case requests.Account:
+		ac, err = a.accountLogin(ctx, req)
+	case requests.MobilePhone:
+		ac, err = a.phoneLogin(ctx, req)
+	case requests.WeiXin:
+		ac, err = a.wxLogin(ctx, req)
+	case requests.Guest:
+		ac, err = a.guestLogin(ctx, req.Account)
+	default:
+		err = biz.RequestDataError
+	}
+	if err != nil {
+		return &pb.LoginResponse{}, msError.GrpcError(err)
+	}
+	return &pb.LoginResponse{
+		Uid: ac.Uid,
+	}, nil
+}
+
+// 账号密码注册逻辑，密码加盐哈希后保存
+func (a *AccountService) accountRegister(ctx context.Context, req *pb.RegisterParams) (*entity.Account, *msError.Error) {
+	if req.Account == "" || req.Password == "" {
+		return nil, biz.RequestDataError
+	}
+	// 1. 账号不能重复
+	exist, err := a.accountDao.FindAccount(ctx, req.Account)
+	if err != nil {
+		logs.Error("find account err:%v", err)
+		return nil, biz.SqlError
+	}
+	if exist != nil {
+		return nil, biz.AccountExist
+	}
+	// 2. 密码不保存明文
+	password, err := utils.HashPassword(req.Password)
+	if err != nil {
+		logs.Error("hash password err:%v", err)
+		return nil, biz.RequestDataError
+	}
+	ac := &entity.Account{
+		Account:    req.Account,
+		Password:   password,
+		CreateTime: time.Now(),
+	}
+	// 3. 生成用户唯一ID，使用Redis自增
+	uid, err := a.redisDao.NextAccountId()
+	if err != nil {
+		return nil, biz.SqlError
+	}
+	ac.Uid = uid
+	err = a.accountDao.SaveAccount(ctx, ac)
+	if err != nil {
+		// 并发注册同一个账号时由唯一索引保证只有一个成功
+		if dao.IsDuplicateKey(err) {
+			return nil, biz.AccountExist
+		}
+		logs.Error("save account err:%v", err)
+		return nil, biz.SqlError
+	}
+	return ac, nil
+}
+
+// 账号密码登录逻辑，账号不存在和密码错误返回同样的错误
+func (a *AccountService) accountLogin(ctx context.Context, req *pb.LoginParams) (*entity.Account, *msError.Error) {
+	if req.Account == "" || req.Password == "" {
+		return nil, biz.RequestDataError
+	}
+	ac, err := a.accountDao.FindAccount(ctx, req.Account)
+	if err != nil {
+		logs.Error("find account err:%v", err)
+		return nil, biz.SqlError
+	}
+	if ac == nil || !utils.CheckPassword(ac.Password, req.Password) {
+		return nil, biz.AccountOrPasswordError
+	}
+	return ac, nil
+}
+
+// 手机号注册逻辑，account 为手机号，需要开启 authPhone 并校验短信验证码
+func (a *AccountService) phoneRegister(ctx context.Context, req *pb.RegisterParams) (*entity.Account, *msError.Error) {
+	if req.Account == "" {
+		return nil, biz.RequestDataError
+	}
+	if err := a.verifySmsCode(ctx, req.Account, req.SmsCode); err != nil {
+		return nil, err
+	}
+	exist, err := a.accountDao.FindAccountByPhone(ctx, req.Account)
+	if err != nil {
+		logs.Error("find account by phone err:%v", err)
+		return nil, biz.SqlError
+	}
+	if exist != nil {
+		return nil, biz.PhoneAlreadyBind
+	}
+	ac := &entity.Account{
+		PhoneAccount: req.Account,
+		CreateTime:   time.Now(),
+	}
+	uid, err := a.redisDao.NextAccountId()
+	if err != nil {
+		return nil, biz.SqlError
+	}
+	ac.Uid = uid
+	err = a.accountDao.SaveAccount(ctx, ac)
+	if err != nil {
+		if dao.IsDuplicateKey(err) {
+			return nil, biz.PhoneAlreadyBind
+		}
+		logs.Error("save account err:%v", err)
+		return nil, biz.SqlError
+	}
+	return ac, nil
+}
+
+// 手机号登录逻辑，需要开启 authPhone 并校验短信验证码
+func (a *AccountService) phoneLogin(ctx context.Context, req *pb.LoginParams) (*entity.Account, *msError.Error) {
+	if req.Account == "" {
+		return nil, biz.RequestDataError
+	}
+	if err := a.verifySmsCode(ctx, req.Account, req.SmsCode); err != nil {
+		return nil, err
+	}
+	ac, err := a.accountDao.FindAccountByPhone(ctx, req.Account)
+	if err != nil {
+		logs.Error("find account by phone err:%v", err)
+		return nil, biz.SqlError
+	}
+	if ac == nil {
+		return nil, biz.NotFindBindPhone
+	}
+	return ac, nil
+}
+
+// 微信账号注册逻辑，已经注册或者由游客绑定过的微信账号直接返回，保持原来的 uid。
+// account 的含义见 wxOpenId：配置了 wx.appId、secret 时为 wx.login 得到的 code，否则为微信账号
+func (a *AccountService) wxRegister(ctx context.Context, req *pb.RegisterParams) (*entity.Account, *msError.Error) {
+	openId, e := a.wxOpenId(ctx, req.Account)
+	if e != nil {
+		return nil, e
+	}
+	exist, err := a.accountDao.FindAccountByWx(ctx, openId)
+	if err != nil {
+		logs.Error("find account by wx err:%v", err)
+		return nil, biz.SqlError
+	}
+	if exist != nil {
+		return exist, nil
+	}
 	// 1. 封装一个account结构，将其存入数据库
 	ac := &entity.Account{
-		WxAccount:  req.Account,
+		WxAccount:  openId,
 		CreateTime: time.Now(),
 	}
 	// 2. 生成用户唯一ID，使用Redis自增
 	uid, err := a.redisDao.NextAccountId()
 	if err != nil {
-		return ac, biz.SqlError
+		return nil, biz.SqlError
 	}
 	ac.Uid = uid
-	err = a.accountDao.SaveAccount(context.TODO(), ac)
+	err = a.accountDao.SaveAccount(ctx, ac)
 	if err != nil {
-		return ac, biz.SqlError
+		// 同一个微信账号并发注册时，另一个请求已经创建了账号
+		if dao.IsDuplicateKey(err) {
+			exist, err = a.accountDao.FindAccountByWx(ctx, openId)
+			if err == nil && exist != nil {
+				return exist, nil
+			}
+		}
+		logs.Error("save account err:%v", err)
+		return nil, biz.SqlError
 	}
 	return ac, nil
 }
+
+// wxOpenId 取出请求中的微信账号。配置了 wx.appId、secret 时，account 必须是客户端 wx.login 得到的 code，
+// 在服务端通过 code2session 校验后返回 openid；没有配置时沿用旧的协议，account 就是微信账号，直接信任客户端
+func (a *AccountService) wxOpenId(ctx context.Context, account string) (string, *msError.Error) {
+	if account == "" {
+		return "", biz.RequestDataError
+	}
+	if !a.wxClient.Enabled() {
+		return account, nil
+	}
+	openId, err := a.wxClient.Code2Session(ctx, account)
+	if err != nil {
+		logs.Warn("wx code2session err:%v", err)
+		return "", biz.WxAuthFailed
+	}
+	return openId, nil
+}
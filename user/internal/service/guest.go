@@ -0,0 +1,148 @@
+package service
+
+import (
+	"common/biz"
+	"common/logs"
+	"context"
+	"core/dao"
+	"core/models/entity"
+	"core/models/requests"
+	"framework/msError"
+	"time"
+	"user/pb"
+)
+
+// BindAccount 把账号（通常是游客账号）绑定到手机号或微信，uid 不变，金币和游戏记录都保留
+func (a *AccountService) BindAccount(ctx context.Context, req *pb.BindAccountParams) (*pb.BindAccountResponse, error) {
+	logs.Info("bind account service called, uid=%s, platform=%d", req.Uid, req.LoginPlatform)
+	if req.Uid == "" || req.Account == "" {
+		return &pb.BindAccountResponse{}, msError.GrpcError(biz.RequestDataError)
+	}
+	var err *msError.Error
+	switch req.LoginPlatform {
+	case requests.MobilePhone:
+		err = a.bindPhone(ctx, req)
+	case requests.WeiXin:
+		err = a.bindWx(ctx, req)
+	default:
+		err = biz.RequestDataError
+	}
+	if err != nil {
+		return &pb.BindAccountResponse{}, msError.GrpcError(err)
+	}
+	return &pb.BindAccountResponse{}, nil
+}
+
+// 绑定手机号，需要开启 authPhone 并校验短信验证码，手机号已经被其它账号绑定时返回 PhoneAlreadyBind
+func (a *AccountService) bindPhone(ctx context.Context, req *pb.BindAccountParams) *msError.Error {
+	if err := a.verifySmsCode(ctx, req.Account, req.SmsCode); err != nil {
+		return err
+	}
+	exist, err := a.accountDao.FindAccountByPhone(ctx, req.Account)
+	if err != nil {
+		logs.Error("find account by phone err:%v", err)
+		return biz.SqlError
+	}
+	if exist != nil {
+		return biz.PhoneAlreadyBind
+	}
+	return a.bind(ctx, req.Uid, "phoneAccount", req.Account, biz.PhoneAlreadyBind)
+}
+
+// 绑定微信，account 的含义见 wxOpenId，微信已经注册过账号时返回 AccountExist
+func (a *AccountService) bindWx(ctx context.Context, req *pb.BindAccountParams) *msError.Error {
+	openId, e := a.wxOpenId(ctx, req.Account)
+	if e != nil {
+		return e
+	}
+	exist, err := a.accountDao.FindAccountByWx(ctx, openId)
+	if err != nil {
+		logs.Error("find account by wx err:%v", err)
+		return biz.SqlError
+	}
+	if exist != nil {
+		return biz.AccountExist
+	}
+	return a.bind(ctx, req.Uid, "wxAccount", openId, biz.AccountExist)
+}
+
+// bind 修改账号的绑定字段，账号不存在返回 AccountNotExist，该字段已经绑定过时返回 boundErr
+func (a *AccountService) bind(ctx context.Context, uid, field, value string, boundErr *msError.Error) *msError.Error {
+	ac, err := a.accountDao.FindAccountByUid(ctx, uid)
+	if err != nil {
+		logs.Error("find account by uid err:%v", err)
+		return biz.SqlError
+	}
+	if ac == nil {
+		return biz.AccountNotExist
+	}
+	ok, err := a.accountDao.BindAccount(ctx, uid, field, value)
+	if err != nil {
+		// 检查之后被其它账号并发绑定
+		if dao.IsDuplicateKey(err) {
+			return boundErr
+		}
+		logs.Error("bind account err:%v, uid=%s", err, uid)
+		return biz.SqlError
+	}
+	if !ok {
+		return boundErr
+	}
+	return nil
+}
+
+// 游客登录，account 为设备 id，设备上还没有游客账号时创建一个，
+// 游客账号绑定手机号或微信以后只能用绑定的方式登录
+func (a *AccountService) guestLogin(ctx context.Context, deviceId string) (*entity.Account, *msError.Error) {
+	if deviceId == "" {
+		return nil, biz.RequestDataError
+	}
+	ac, err := a.accountDao.FindGuestAccount(ctx, deviceId)
+	if err != nil {
+		logs.Error("find guest account err:%v", err)
+		return nil, biz.SqlError
+	}
+	if ac != nil {
+		return ac, nil
+	}
+	ac = &entity.Account{
+		DeviceId:   deviceId,
+		Guest:      true,
+		CreateTime: time.Now(),
+	}
+	uid, err := a.redisDao.NextAccountId()
+	if err != nil {
+		return nil, biz.SqlError
+	}
+	ac.Uid = uid
+	err = a.accountDao.SaveAccount(ctx, ac)
+	if err != nil {
+		// 同一设备并发登录时，另一个请求已经创建了游客账号
+		if dao.IsDuplicateKey(err) {
+			ac, err = a.accountDao.FindGuestAccount(ctx, deviceId)
+			if err == nil && ac != nil {
+				return ac, nil
+			}
+		}
+		logs.Error("save account err:%v", err)
+		return nil, biz.SqlError
+	}
+	return ac, nil
+}
+
+// 微信登录，account 的含义见 wxOpenId，微信账号还没有注册时返回 AccountNotExist
+func (a *AccountService) wxLogin(ctx context.Context, req *pb.LoginParams) (*entity.Account, *msError.Error) {
+	openId, e := a.wxOpenId(ctx, req.Account)
+	if e != nil {
+		return nil, e
+	}
+	ac, err := a.accountDao.FindAccountByWx(ctx, openId)
+	if err != nil {
+		logs.Error("find account by wx err:%v", err)
+		return nil, biz.SqlError
+	}
+	if ac == nil {
+		return nil, biz.AccountNotExist
+	}
+	return ac, nil
+}
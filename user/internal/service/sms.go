@@ -0,0 +1,100 @@
+package service
+
+import (
+	"common/biz"
+	"common/logs"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"framework/game"
+	"framework/msError"
+	"math/big"
+	"regexp"
+	"user/pb"
+)
+
+// smsMaxFails 同一个验证码允许输错的次数
+const smsMaxFails = 5
+
+// 手机号只校验格式：可选的 + 号和 6 到 15 位数字
+var phoneRegexp = regexp.MustCompile(`^\+?\d{6,15}$`)
+
+// SendSmsCode 发送短信验证码，同一手机号在发送间隔内只能发送一次，authPhone 关闭时验证码不会被接受，不再发送
+func (a *AccountService) SendSmsCode(ctx context.Context, req *pb.SmsCodeParams) (*pb.SmsCodeResponse, error) {
+	if !game.Conf.GetBool("authPhone") {
+		return &pb.SmsCodeResponse{}, msError.GrpcError(biz.PhoneAuthDisabled)
+	}
+	if !phoneRegexp.MatchString(req.Phone) {
+		return &pb.SmsCodeResponse{}, msError.GrpcError(biz.RequestDataError)
+	}
+	ok, err := a.smsDao.Acquire(ctx, req.Phone)
+	if err != nil {
+		logs.Error("acquire sms interval err:%v, phone=%s", err, req.Phone)
+		return &pb.SmsCodeResponse{}, msError.GrpcError(biz.SqlError)
+	}
+	if !ok {
+		return &pb.SmsCodeResponse{}, msError.GrpcError(biz.RequestTooFrequent)
+	}
+	code, err := newSmsCode()
+	if err == nil {
+		err = a.smsDao.SaveCode(ctx, req.Phone, code)
+	}
+	if err == nil {
+		err = a.smsProvider.SendCode(ctx, req.Phone, code)
+	}
+	if err != nil {
+		logs.Error("send sms code err:%v, phone=%s", err, req.Phone)
+		// 发送失败不占用发送间隔，客户端可以立即重试
+		if err := a.smsDao.Release(ctx, req.Phone); err != nil {
+			logs.Error("release sms interval err:%v, phone=%s", err, req.Phone)
+		}
+		return &pb.SmsCodeResponse{}, msError.GrpcError(biz.SmsSendFailed)
+	}
+	return &pb.SmsCodeResponse{}, nil
+}
+
+// verifySmsCode 校验短信验证码，校验通过后验证码失效，连续错误 smsMaxFails 次后验证码也失效，需要重新发送。
+// gameConfig.json 中 authPhone 关闭时无法确认手机号归属，拒绝手机号注册、登录和绑定
+func (a *AccountService) verifySmsCode(ctx context.Context, phone, code string) *msError.Error {
+	if !game.Conf.GetBool("authPhone") {
+		return biz.PhoneAuthDisabled
+	}
+	if code == "" {
+		return biz.SmsCodeError
+	}
+	saved, err := a.smsDao.GetCode(ctx, phone)
+	if err != nil {
+		logs.Error("get sms code err:%v, phone=%s", err, phone)
+		return biz.SqlError
+	}
+	if saved == "" {
+		return biz.SmsCodeError
+	}
+	if subtle.ConstantTimeCompare([]byte(saved), []byte(code)) != 1 {
+		fails, err := a.smsDao.IncrFail(ctx, phone)
+		if err != nil {
+			logs.Error("incr sms fail err:%v, phone=%s", err, phone)
+		}
+		if err != nil || fails >= smsMaxFails {
+			logs.Warn("sms code failed too many times, phone=%s", phone)
+			if err := a.smsDao.DeleteCode(ctx, phone); err != nil {
+				logs.Error("delete sms code err:%v, phone=%s", err, phone)
+			}
+		}
+		return biz.SmsCodeError
+	}
+	if err := a.smsDao.DeleteCode(ctx, phone); err != nil {
+		logs.Error("delete sms code err:%v, phone=%s", err, phone)
+	}
+	return nil
+}
+
+// newSmsCode 生成 6 位数字验证码
+func newSmsCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
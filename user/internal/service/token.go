@@ -0,0 +1,36 @@
+package service
+
+import (
+	"common/biz"
+	"common/logs"
+	"context"
+	"core/dao"
+	"framework/msError"
+	"time"
+	"user/pb"
+)
+
+// RevokeToken 吊销 token，revoked 为 false 表示 token 之前已经被吊销过，
+// refresh token 换取新 token 时依靠它保证只能使用一次；kick 为 true 时踢下线该用户的所有连接
+func (a *AccountService) RevokeToken(ctx context.Context, req *pb.RevokeTokenParams) (*pb.RevokeTokenResponse, error) {
+	if req.TokenId == "" {
+		return &pb.RevokeTokenResponse{}, msError.GrpcError(biz.RequestDataError)
+	}
+	revoked, err := a.tokenDao.Revoke(ctx, req.TokenId, time.Until(time.Unix(req.ExpireAt, 0)))
+	if err != nil {
+		logs.Error("revoke token err:%v, uid=%s", err, req.Uid)
+		return &pb.RevokeTokenResponse{}, msError.GrpcError(biz.SqlError)
+	}
+	if req.Kick && req.Uid != "" {
+		if err := a.tokenDao.Kick(ctx, dao.KickMsg{
+			Uid:    req.Uid,
+			Code:   biz.TokenInfoError.Code,
+			Reason: biz.TokenInfoError.Error(),
+		}); err != nil {
+			logs.Error("kick user err:%v, uid=%s", err, req.Uid)
+		}
+	}
+	return &pb.RevokeTokenResponse{
+		Revoked: revoked,
+	}, nil
+}
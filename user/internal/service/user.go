@@ -0,0 +1,231 @@
+package service
+
+import (
+	"common/biz"
+	"common/logs"
+	"common/utils"
+	"context"
+	"core/dao"
+	"core/models/entity"
+	"fmt"
+	"framework/game"
+	"framework/msError"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+	"user/pb"
+)
+
+// user 服务是 user 集合的唯一写入方，其他服务通过下面的 RPC 读取和修改用户数据
+
+// profileFields UpdateProfile 允许修改的字段
+var profileFields = map[string]bool{
+	"nickname": true,
+	"avatar":   true,
+	"sex":      true,
+	"address":  true,
+	"location": true,
+}
+
+// GetUser 查询用户，create 为 true 且用户不存在时使用请求中的资料创建新用户，
+// loginIp 不为空时表示用户进入游戏，记录最后登录的时间和 IP
+func (a *AccountService) GetUser(ctx context.Context, req *pb.GetUserParams) (*pb.GetUserResponse, error) {
+	if req.Uid == "" {
+		return &pb.GetUserResponse{}, msError.GrpcError(biz.RequestDataError)
+	}
+	user, err := a.userDao.FindUserByUid(ctx, req.Uid)
+	if err != nil {
+		logs.Error("GetUser find user err:%v, uid=%s", err, req.Uid)
+		return &pb.GetUserResponse{}, msError.GrpcError(biz.SqlError)
+	}
+	if user == nil {
+		if !req.Create {
+			return &pb.GetUserResponse{}, msError.GrpcError(biz.NotFindUser)
+		}
+		// 新增
+		user = &entity.User{}
+		user.Uid = req.Uid
+		user.Gold = startGold()
+		user.Avatar = utils.Default(req.Avatar, "common/head_icon_default")
+		user.Nickname = utils.Default(req.Nickname, fmt.Sprintf("%s%s", "QHXRPG", req.Uid))
+		user.Sex = int(req.Sex)
+		user.CreateTime = time.Now().UnixMilli()
+		user.LastLoginTime = time.Now().UnixMilli()
+		user.LastLoginIp = req.LoginIp
+		if err := a.userDao.Insert(ctx, user); err != nil {
+			logs.Error("GetUser insert user err:%v, uid=%s", err, req.Uid)
+			return &pb.GetUserResponse{}, msError.GrpcError(biz.SqlError)
+		}
+	} else if req.LoginIp != "" {
+		updated, err := a.userDao.UpdateUserByUid(ctx, req.Uid, bson.M{
+			"lastLoginTime": time.Now().UnixMilli(),
+			"lastLoginIp":   req.LoginIp,
+		})
+		if err != nil {
+			logs.Error("GetUser update login ip err:%v, uid=%s", err, req.Uid)
+			return &pb.GetUserResponse{}, msError.GrpcError(biz.SqlError)
+		}
+		if updated != nil {
+			user = updated
+		}
+	}
+	return &pb.GetUserResponse{
+		User: toPbUser(user),
+	}, nil
+}
+
+// BatchGetUsers 批量查询用户，不存在的用户不在结果中
+func (a *AccountService) BatchGetUsers(ctx context.Context, req *pb.BatchGetUsersParams) (*pb.BatchGetUsersResponse, error) {
+	if len(req.Uids) == 0 {
+		return &pb.BatchGetUsersResponse{}, nil
+	}
+	users, err := a.userDao.FindUsersByUids(ctx, req.Uids)
+	if err != nil {
+		logs.Error("BatchGetUsers err:%v", err)
+		return &pb.BatchGetUsersResponse{}, msError.GrpcError(biz.SqlError)
+	}
+	res := &pb.BatchGetUsersResponse{
+		Users: make([]*pb.User, 0, len(users)),
+	}
+	for _, user := range users {
+		res.Users = append(res.Users, toPbUser(user))
+	}
+	return res, nil
+}
+
+// UpdateProfile 修改用户资料，只修改 fields 中列出的字段
+func (a *AccountService) UpdateProfile(ctx context.Context, req *pb.UpdateProfileParams) (*pb.UpdateProfileResponse, error) {
+	if req.Uid == "" || len(req.Fields) == 0 {
+		return &pb.UpdateProfileResponse{}, msError.GrpcError(biz.RequestDataError)
+	}
+	values := map[string]any{
+		"nickname": req.Nickname,
+		"avatar":   req.Avatar,
+		"sex":      int(req.Sex),
+		"address":  req.Address,
+		"location": req.Location,
+	}
+	set := bson.M{}
+	for _, field := range req.Fields {
+		if !profileFields[field] {
+			return &pb.UpdateProfileResponse{}, msError.GrpcError(biz.RequestDataError)
+		}
+		set[field] = values[field]
+	}
+	user, err := a.userDao.UpdateUserByUid(ctx, req.Uid, set)
+	if err != nil {
+		logs.Error("UpdateProfile err:%v, uid=%s", err, req.Uid)
+		return &pb.UpdateProfileResponse{}, msError.GrpcError(biz.SqlError)
+	}
+	if user == nil {
+		return &pb.UpdateProfileResponse{}, msError.GrpcError(biz.NotFindUser)
+	}
+	return &pb.UpdateProfileResponse{
+		User: toPbUser(user),
+	}, nil
+}
+
+// BlockAccount 冻结帐号，expireAt（毫秒）为 0 时永久冻结，冻结后立即踢下线该用户的所有连接
+func (a *AccountService) BlockAccount(ctx context.Context, req *pb.BlockAccountParams) (*pb.BlockAccountResponse, error) {
+	if req.Uid == "" || (req.ExpireAt != 0 && req.ExpireAt <= time.Now().UnixMilli()) {
+		return &pb.BlockAccountResponse{}, msError.GrpcError(biz.RequestDataError)
+	}
+	if err := a.setBlocked(ctx, req.Uid, bson.M{
+		"isBlockedAccount": 1,
+		"blockReason":      req.Reason,
+		"blockExpireAt":    req.ExpireAt,
+	}); err != nil {
+		return &pb.BlockAccountResponse{}, msError.GrpcError(err)
+	}
+	logs.Info("block account uid=%s, reason=%s, expireAt=%d", req.Uid, req.Reason, req.ExpireAt)
+	if err := a.tokenDao.Kick(ctx, dao.KickMsg{
+		Uid:    req.Uid,
+		Code:   biz.BlockedAccount.Code,
+		Reason: utils.Default(req.Reason, biz.BlockedAccount.Error()),
+	}); err != nil {
+		logs.Error("kick blocked user err:%v, uid=%s", err, req.Uid)
+	}
+	return &pb.BlockAccountResponse{}, nil
+}
+
+// UnblockAccount 解冻帐号
+func (a *AccountService) UnblockAccount(ctx context.Context, req *pb.BlockAccountParams) (*pb.BlockAccountResponse, error) {
+	if req.Uid == "" {
+		return &pb.BlockAccountResponse{}, msError.GrpcError(biz.RequestDataError)
+	}
+	if err := a.setBlocked(ctx, req.Uid, bson.M{
+		"isBlockedAccount": 0,
+		"blockReason":      "",
+		"blockExpireAt":    0,
+	}); err != nil {
+		return &pb.BlockAccountResponse{}, msError.GrpcError(err)
+	}
+	logs.Info("unblock account uid=%s", req.Uid)
+	return &pb.BlockAccountResponse{}, nil
+}
+
+func (a *AccountService) setBlocked(ctx context.Context, uid string, set bson.M) *msError.Error {
+	user, err := a.userDao.UpdateUserByUid(ctx, uid, set)
+	if err != nil {
+		logs.Error("set blocked err:%v, uid=%s", err, uid)
+		return biz.SqlError
+	}
+	if user == nil {
+		return biz.NotFindUser
+	}
+	return nil
+}
+
+// AdjustGold 原子地增减用户金币，delta 为负数时金币不足返回 NotEnoughGold
+func (a *AccountService) AdjustGold(ctx context.Context, req *pb.AdjustGoldParams) (*pb.AdjustGoldResponse, error) {
+	if req.Uid == "" || req.Delta == 0 {
+		return &pb.AdjustGoldResponse{}, msError.GrpcError(biz.RequestDataError)
+	}
+	user, err := a.userDao.IncGoldByUid(ctx, req.Uid, req.Delta)
+	if err != nil {
+		logs.Error("AdjustGold err:%v, uid=%s", err, req.Uid)
+		return &pb.AdjustGoldResponse{}, msError.GrpcError(biz.SqlError)
+	}
+	if user == nil {
+		// 没有更新到文档：用户不存在或者金币不足
+		exist, err := a.userDao.FindUserByUid(ctx, req.Uid)
+		if err != nil {
+			logs.Error("AdjustGold find user err:%v, uid=%s", err, req.Uid)
+			return &pb.AdjustGoldResponse{}, msError.GrpcError(biz.SqlError)
+		}
+		if exist == nil {
+			return &pb.AdjustGoldResponse{}, msError.GrpcError(biz.NotFindUser)
+		}
+		return &pb.AdjustGoldResponse{}, msError.GrpcError(biz.NotEnoughGold)
+	}
+	logs.Info("adjust gold uid=%s, delta=%d, gold=%d, reason=%s", req.Uid, req.Delta, user.Gold, req.Reason)
+	return &pb.AdjustGoldResponse{
+		Gold: user.Gold,
+	}, nil
+}
+
+// startGold 新用户的初始金币，来自游戏配置
+func startGold() int64 {
+	if v, ok := game.Conf.GameConfig["startGold"]["value"].(float64); ok {
+		return int64(v)
+	}
+	return 0
+}
+
+func toPbUser(user *entity.User) *pb.User {
+	return &pb.User{
+		Uid:              user.Uid,
+		Nickname:         user.Nickname,
+		Avatar:           user.Avatar,
+		Sex:              int32(user.Sex),
+		Gold:             user.Gold,
+		IsBlockedAccount: int32(user.IsBlockedAccount),
+		BlockReason:      user.BlockReason,
+		BlockExpireAt:    user.BlockExpireAt,
+		MobilePhone:      user.MobilePhone,
+		Address:          user.Address,
+		Location:         user.Location,
+		CreateTime:       user.CreateTime,
+		LastLoginTime:    user.LastLoginTime,
+		LastLoginIp:      user.LastLoginIp,
+	}
+}
@@ -0,0 +1,29 @@
+package sms
+
+import (
+	"common/logs"
+	"context"
+)
+
+// Provider 短信服务商，接入真实的短信服务时实现这个接口
+type Provider interface {
+	SendCode(ctx context.Context, phone, code string) error
+}
+
+// LogProvider 不发送短信，只把验证码打印到日志中，用于本地开发和测试
+type LogProvider struct{}
+
+func (LogProvider) SendCode(ctx context.Context, phone, code string) error {
+	logs.Info("sms code, phone=%s, code=%s", phone, code)
+	return nil
+}
+
+// NewProvider 根据配置的名称创建短信服务商，未知的名称使用 LogProvider
+func NewProvider(name string) Provider {
+	switch name {
+	case "", "log":
+	default:
+		logs.Warn("unknown sms provider:%s, use log provider", name)
+	}
+	return LogProvider{}
+}
@@ -0,0 +1,78 @@
+package wx
+
+import (
+	"common/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// code2SessionUrl 微信小游戏的登录凭证校验接口
+const code2SessionUrl = "https://api.weixin.qq.com/sns/jscode2session"
+
+// ErrNotConfigured 没有配置 appId 和 secret，无法校验微信登录
+var ErrNotConfigured = errors.New("wx appId or secret not configured")
+
+// Client 在服务端校验客户端 wx.login 得到的 code，换取微信用户的 openid，
+// 客户端上报的微信账号不可信，注册、登录、绑定都以这里返回的 openid 为准
+type Client struct {
+	appId  string
+	secret string
+	http   *http.Client
+}
+
+type code2SessionResponse struct {
+	OpenId  string `json:"openid"`
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// Enabled 是否配置了 appId 和 secret，没有配置时无法校验 code
+func (c *Client) Enabled() bool {
+	return c.appId != "" && c.secret != ""
+}
+
+// Code2Session 校验 code 并返回 openid，code 只能使用一次
+func (c *Client) Code2Session(ctx context.Context, code string) (string, error) {
+	if !c.Enabled() {
+		return "", ErrNotConfigured
+	}
+	query := url.Values{}
+	query.Set("appid", c.appId)
+	query.Set("secret", c.secret)
+	query.Set("js_code", code)
+	query.Set("grant_type", "authorization_code")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, code2SessionUrl+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("code2session status:%d", resp.StatusCode)
+	}
+	var result code2SessionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.ErrCode != 0 || result.OpenId == "" {
+		return "", fmt.Errorf("code2session errcode:%d, errmsg:%s", result.ErrCode, result.ErrMsg)
+	}
+	return result.OpenId, nil
+}
+
+// NewClient 根据配置创建微信登录校验客户端，未配置时 Enabled 返回 false
+func NewClient(conf config.WxConf) *Client {
+	return &Client{
+		appId:  conf.AppId,
+		secret: conf.Secret,
+		http:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
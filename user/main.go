@@ -6,6 +6,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"framework/game"
 	"log"
 	"os"
 	"user/app"
@@ -14,10 +15,14 @@ import (
 // go run main.go -config=custom_config.yml
 var configFile = flag.String("config", "application.yml", "config file")
 
+// gameConfig.json 所在的目录，注册登录时读取 authPhone 等配置
+var gameConfigDir = flag.String("gameDir", "../config", "game config dir")
+
 func main() {
 	// 读取配置文件
 	flag.Parse()                   // 解析
 	config.InitConfig(*configFile) // 加载配置
+	game.InitConfig(*gameConfigDir)
 
 	// 启动内存监控, 放入协程当中启动
 	// 点击：http://localhost:5854/debug/statsviz